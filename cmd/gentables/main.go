@@ -0,0 +1,310 @@
+// Commande gentables : génère les structures Go des tables d'une base de données
+//
+//	go run ./cmd/gentables -database base -out models -package models
+//	go run ./cmd/gentables -connection main -tables users,orders -out models
+//
+// Pour chaque table, un fichier contient la structure avec les tags db, json et validate,
+// un Validator à compléter et le constructeur de la tables.Table correspondante.
+// La connexion est celle de ConnectDatabase (variables DB_*) ou une connexion nommée
+// d'un fichier de configuration (-config)
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/jsavajols/goframework/functions/database"
+)
+
+func main() {
+	dbName := flag.String("database", "", "base de données (DB_NAME par défaut)")
+	dialect := flag.String("dialect", "", "dialecte (DB_DIALECT par défaut)")
+	config := flag.String("config", "", "fichier de configuration des connexions nommées")
+	connection := flag.String("connection", "", "nom de la connexion, utilisé par les constructeurs générés")
+	schema := flag.String("schema", "", "schéma des tables (schéma courant par défaut)")
+	tableList := flag.String("tables", "", "tables à générer séparées par des virgules (toutes par défaut)")
+	out := flag.String("out", "models", "répertoire des fichiers générés")
+	pkg := flag.String("package", "", "nom du package (nom du répertoire par défaut)")
+	force := flag.Bool("force", false, "remplace les fichiers existants")
+	flag.Parse()
+
+	if *config != "" {
+		if err := database.RegisterFile(*config); err != nil {
+			exit(err)
+		}
+	}
+	var err error
+	var db database.Queryer
+	var dialectName string
+	if *connection != "" {
+		db, dialectName, err = database.Connection(*connection)
+	} else {
+		db, dialectName, err = database.GetDatabase(*dbName, *dialect)
+	}
+	if err != nil {
+		exit(err)
+	}
+	defer database.CloseDatabases()
+
+	ctx := context.Background()
+	names := []string{}
+	if *tableList != "" {
+		for _, name := range strings.Split(*tableList, ",") {
+			names = append(names, strings.TrimSpace(name))
+		}
+	} else if names, err = database.ListTables(ctx, db, dialectName, *schema); err != nil {
+		exit(err)
+	}
+
+	if *pkg == "" {
+		abs, _ := filepath.Abs(*out)
+		*pkg = strings.ToLower(goIdentifier(filepath.Base(abs)))
+	}
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		exit(err)
+	}
+	for _, name := range names {
+		tableName := name
+		if *schema != "" && !strings.Contains(name, ".") {
+			tableName = *schema + "." + name
+		}
+		tableSchema, err := database.Describe(ctx, db, dialectName, tableName)
+		if err != nil {
+			exit(err)
+		}
+		source, err := generate(*pkg, tableSchema, dialectName, *dbName, *connection)
+		if err != nil {
+			exit(fmt.Errorf("%s : %w", name, err))
+		}
+		path := filepath.Join(*out, fileName(name)+".go")
+		if _, err := os.Stat(path); err == nil && !*force {
+			fmt.Println("Fichier existant ignoré :", path)
+			continue
+		}
+		if err := os.WriteFile(path, source, 0644); err != nil {
+			exit(err)
+		}
+		fmt.Println("Généré :", path)
+	}
+}
+
+type field struct {
+	Name string
+	Type string
+	Tag  string
+}
+
+type model struct {
+	Package    string
+	Type       string
+	Table      string
+	PrimaryKey string
+	Dialect    string
+	Database   string
+	Connection string
+	Fields     []field
+	// Imports packages de la bibliothèque standard utilisés par le fichier
+	Imports []string
+}
+
+var fileTemplate = template.Must(template.New("model").Parse(`// Code généré par gentables à partir de la table {{.Table}}, à compléter dans le Validator.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	"{{.}}"
+{{- end}}
+{{if .Imports}}
+{{end -}}
+	"github.com/jsavajols/goframework/functions/tables"
+)
+
+// {{.Type}} enregistrement de la table {{.Table}}
+type {{.Type}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} ` + "`{{.Tag}}`" + `
+{{- end}}
+}
+
+// {{.Type}}Validator hooks de la table {{.Table}}
+type {{.Type}}Validator struct {
+	tables.DefaultValidator
+}
+
+// ValidateRecord contrôle de l'enregistrement
+func (v {{.Type}}Validator) ValidateRecord() error {
+	return nil
+}
+
+// BeforeInsertRecord appelé avant l'insertion, peut modifier l'enregistrement
+func (v {{.Type}}Validator) BeforeInsertRecord(ctx context.Context, record *tables.Record) error {
+	return v.ValidateRecord()
+}
+
+// BeforeUpdateRecord appelé avant la mise à jour, peut modifier les colonnes mises à jour
+func (v {{.Type}}Validator) BeforeUpdateRecord(ctx context.Context, record *tables.Record) error {
+	return v.ValidateRecord()
+}
+
+// New{{.Type}}Table retourne la Table {{.Table}}
+func New{{.Type}}Table() tables.Table {
+	return tables.Table{
+{{- if .Connection}}
+		Connection: {{printf "%q" .Connection}},
+{{- else}}
+		Dialect:    {{printf "%q" .Dialect}},
+{{- if .Database}}
+		Database:   {{printf "%q" .Database}},
+{{- end}}
+{{- end}}
+		TableName:  {{printf "%q" .Table}},
+{{- if .PrimaryKey}}
+		PrimaryKey: {{printf "%q" .PrimaryKey}},
+{{- end}}
+		Struct:     {{.Type}}{},
+		Validator:  {{.Type}}Validator{},
+	}
+}
+`))
+
+// generate retourne le source Go de la table
+func generate(pkg string, schema database.TableSchema, dialect, dbName, connection string) ([]byte, error) {
+	m := model{
+		Package:    pkg,
+		Type:       goIdentifier(schema.Name),
+		Table:      schema.Name,
+		Dialect:    dialect,
+		Database:   dbName,
+		Connection: connection,
+	}
+	if len(schema.PrimaryKey) == 1 {
+		m.PrimaryKey = schema.PrimaryKey[0]
+	}
+	imports := map[string]bool{"context": true}
+	used := map[string]bool{}
+	for _, column := range schema.Columns {
+		goType, importPath := goType(column)
+		if importPath != "" {
+			imports[importPath] = true
+		}
+		name := goIdentifier(column.Name)
+		for used[name] {
+			name += "_"
+		}
+		used[name] = true
+		options := ""
+		if column.PrimaryKey {
+			options += ",pk"
+		}
+		if column.AutoIncrement {
+			options += ",autoincrement"
+		}
+		tag := fmt.Sprintf(`db:"%s%s" json:"%s"`, column.Name, options, column.Name)
+		if rules := validateRules(column, goType); rules != "" {
+			tag += ` validate:"` + rules + `"`
+		}
+		m.Fields = append(m.Fields, field{Name: name, Type: goType, Tag: tag})
+	}
+	for path := range imports {
+		m.Imports = append(m.Imports, path)
+	}
+	sort.Strings(m.Imports)
+
+	var buffer bytes.Buffer
+	if err := fileTemplate.Execute(&buffer, m); err != nil {
+		return nil, err
+	}
+	return format.Source(buffer.Bytes())
+}
+
+// validateRules règles du tag validate d'une colonne : required si elle n'accepte pas null
+// et n'a pas de valeur par défaut, max pour la longueur des colonnes texte
+func validateRules(column database.Column, goType string) string {
+	var rules []string
+	if !column.Nullable && column.Default == nil && !column.AutoIncrement {
+		rules = append(rules, "required")
+	}
+	if column.MaxLength > 0 && strings.TrimPrefix(goType, "*") == "string" {
+		rules = append(rules, fmt.Sprintf("max=%d", column.MaxLength))
+	}
+	return strings.Join(rules, ",")
+}
+
+// goType type Go d'une colonne, pointeur si elle accepte null, et l'import nécessaire
+func goType(column database.Column) (string, string) {
+	sqlType := strings.ToLower(column.Type)
+	if i := strings.IndexAny(sqlType, "( "); i > 0 && !strings.HasPrefix(sqlType, "double") && !strings.HasPrefix(sqlType, "timestamp") {
+		sqlType = sqlType[:i]
+	}
+	goType, importPath := "string", ""
+	switch {
+	case sqlType == "bit" || strings.HasPrefix(sqlType, "bool"):
+		goType = "bool"
+	case sqlType != "interval" && (strings.HasPrefix(sqlType, "int") || strings.HasSuffix(sqlType, "int") || strings.Contains(sqlType, "serial")):
+		goType = "int64"
+	case strings.HasPrefix(sqlType, "dec") || strings.HasPrefix(sqlType, "num") || strings.HasPrefix(sqlType, "real") ||
+		strings.HasPrefix(sqlType, "double") || strings.HasPrefix(sqlType, "float") || strings.Contains(sqlType, "money"):
+		goType = "float64"
+	case strings.HasPrefix(sqlType, "date") || strings.HasPrefix(sqlType, "time"):
+		goType, importPath = "time.Time", "time"
+	case strings.Contains(sqlType, "blob") || strings.Contains(sqlType, "binary") || sqlType == "bytea" || sqlType == "image":
+		return "[]byte", ""
+	}
+	if column.Nullable {
+		goType = "*" + goType
+	}
+	return goType, importPath
+}
+
+// fileName nom du fichier d'une table : schema.user_accounts devient user_accounts
+func fileName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.ToLower(strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), "_"))
+}
+
+// initialisms sigles écrits en majuscules dans les noms Go
+var initialisms = map[string]bool{"id": true, "url": true, "uuid": true, "api": true, "http": true, "json": true, "sql": true, "ip": true, "html": true}
+
+// goIdentifier convertit un nom de table ou de colonne en identifiant Go exporté : user_id devient UserID
+func goIdentifier(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var identifier strings.Builder
+	for _, word := range words {
+		if initialisms[strings.ToLower(word)] {
+			identifier.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		identifier.WriteString(string(runes))
+	}
+	result := identifier.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "T" + result
+	}
+	return result
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "Erreur :", err)
+	os.Exit(1)
+}
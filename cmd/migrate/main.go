@@ -0,0 +1,100 @@
+// Commande migrate : applique les migrations du schéma
+//
+//	go run ./cmd/migrate -dir migrations -database base status
+//	go run ./cmd/migrate -dir migrations -connection main up
+//	go run ./cmd/migrate -dir migrations down -steps 2
+//	go run ./cmd/migrate -dir migrations redo
+//
+// La connexion est celle de ConnectDatabase (variables DB_*) ou une connexion nommée
+// d'un fichier de configuration (-config)
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jsavajols/goframework/functions/database"
+	"github.com/jsavajols/goframework/functions/migrations"
+)
+
+func main() {
+	dir := flag.String("dir", "migrations", "répertoire des fichiers de migration")
+	dbName := flag.String("database", "", "base de données (DB_NAME par défaut)")
+	dialect := flag.String("dialect", "", "dialecte (DB_DIALECT par défaut)")
+	config := flag.String("config", "", "fichier de configuration des connexions nommées")
+	connection := flag.String("connection", "", "nom de la connexion")
+	table := flag.String("table", migrations.DefaultTable, "table des versions appliquées")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage : migrate [options] status|up|down|redo [-steps n]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := flag.Arg(0)
+	commandFlags := flag.NewFlagSet(command, flag.ExitOnError)
+	steps := commandFlags.Int("steps", 0, "nombre de migrations (toutes pour up, une pour down)")
+	commandFlags.Parse(flag.Args()[1:])
+
+	if *config != "" {
+		if err := database.RegisterFile(*config); err != nil {
+			exit(err)
+		}
+	}
+	var err error
+	migrator := &migrations.Migrator{Dir: *dir, Table: *table}
+	if *connection != "" {
+		migrator.Db, migrator.Dialect, err = database.Connection(*connection)
+	} else {
+		migrator.Db, migrator.Dialect, err = database.GetDatabase(*dbName, *dialect)
+	}
+	if err != nil {
+		exit(err)
+	}
+	defer database.CloseDatabases()
+
+	ctx := context.Background()
+	switch command {
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			exit(err)
+		}
+		for _, s := range status {
+			state := "en attente"
+			if s.Applied {
+				state = "appliquée le " + s.AppliedAt
+			}
+			fmt.Printf("%6d  %-40s %s\n", s.Version, s.Name, state)
+		}
+	case "up":
+		count, err := migrator.Up(ctx, *steps)
+		fmt.Println(count, "migration(s) appliquée(s)")
+		if err != nil {
+			exit(err)
+		}
+	case "down":
+		count, err := migrator.Down(ctx, *steps)
+		fmt.Println(count, "migration(s) annulée(s)")
+		if err != nil {
+			exit(err)
+		}
+	case "redo":
+		if err := migrator.Redo(ctx); err != nil {
+			exit(err)
+		}
+		fmt.Println("Dernière migration réappliquée")
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "Erreur :", err)
+	os.Exit(1)
+}
@@ -4,4 +4,5 @@ const (
 	QUOTE               = "\""
 	ROWS_LIMIT          = 1000
 	TIMEOUT_PRESING_URL = 60
+	BULK_BATCH_SIZE     = 500
 )
@@ -0,0 +1,73 @@
+package database_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jsavajols/goframework/functions/database"
+)
+
+func TestConfigFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want database.Config
+	}{
+		{
+			"mysql://u:p@h/b?charset=utf8mb4&parseTime=true&tls=require&timeout=5s",
+			database.Config{Dialect: "mysql", Host: "h", Port: "3306", User: "u", Password: "p", Database: "b", TLS: "require",
+				Charset: "utf8mb4", ParseTime: true, Params: map[string]string{"timeout": "5s"}},
+		},
+		{
+			"postgresql://u:p@h:6543/b?sslmode=verify-full&readPolicy=least-connections",
+			database.Config{Dialect: "postgres", Host: "h", Port: "6543", User: "u", Password: "p", Database: "b", TLS: "verify-full",
+				ReadPolicy: "least-connections"},
+		},
+		{
+			"postgres://h/b?replicas=r1:5433,r2",
+			database.Config{Dialect: "postgres", Host: "h", Port: "5432", Database: "b",
+				Replicas: []database.Config{{Host: "r1", Port: "5433"}, {Host: "r2"}}},
+		},
+		{
+			"sqlite:///tmp/x.db?foreign_keys=on",
+			database.Config{Dialect: "sqlite3", Database: "/tmp/x.db", Pragmas: map[string]string{"foreign_keys": "on"}},
+		},
+		{
+			"sqlite3:x.db",
+			database.Config{Dialect: "sqlite3", Database: "x.db"},
+		},
+		{
+			"sqlserver://u:p@h/b?encrypt=true&name=main",
+			database.Config{Name: "main", Dialect: "mssql", Host: "h", Port: "1433", User: "u", Password: "p", Database: "b", TLS: "verify-full"},
+		},
+		{
+			"mssql://u:p@h:1500/b?encrypt=strict",
+			database.Config{Dialect: "mssql", Host: "h", Port: "1500", User: "u", Password: "p", Database: "b", TLS: "strict"},
+		},
+		{
+			"mssql://h/b?encrypt=false",
+			database.Config{Dialect: "mssql", Host: "h", Port: "1433", Database: "b", TLS: "false"},
+		},
+		{
+			"mssql://h/b?encrypt=disable",
+			database.Config{Dialect: "mssql", Host: "h", Port: "1433", Database: "b", TLS: "disable"},
+		},
+	}
+	for _, test := range tests {
+		got, err := database.ConfigFromURL(test.url)
+		if err != nil {
+			t.Errorf("ConfigFromURL(%q) : %v", test.url, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ConfigFromURL(%q) =\n%+v\nattendu\n%+v", test.url, got, test.want)
+		}
+	}
+}
+
+func TestConfigFromURLErrors(t *testing.T) {
+	for _, url := range []string{"oracle://h/b", "mssql://h/b?encrypt=maybe", "://h"} {
+		if _, err := database.ConfigFromURL(url); err == nil {
+			t.Errorf("ConfigFromURL(%q) : erreur attendue", url)
+		}
+	}
+}
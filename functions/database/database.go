@@ -2,42 +2,218 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/jsavajols/goframework/functions/dialects"
 	"github.com/jsavajols/goframework/functions/logs"
 
 	_ "github.com/lib/pq"
 	_ "github.com/mattn/go-sqlite3"
+	_ "github.com/microsoft/go-mssqldb"
 )
 
-// ConnectDatabase connecte la base de données
+// PoolConfig paramètres des pools de connexions partagés
+// Une valeur à 0 conserve le comportement par défaut de database/sql
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// pool connexion partagée et paramètres propres à la connexion
+type pool struct {
+	db     *sql.DB
+	config PoolConfig
+}
+
+var (
+	poolsMutex sync.Mutex
+	pools      = map[string]*pool{}
+	configs    = map[string]Config{}
+	poolConfig = PoolConfigFromEnv()
+)
+
+// ConnectDatabase connecte la base de données avec la configuration des variables d'environnement
+// (voir ConfigFromEnv), database et dialect remplacent DB_NAME et DB_DIALECT s'ils sont renseignés
 func ConnectDatabase(database string, dialect ...string) (*sql.DB, string, error) {
+	config := ConfigFromEnv("")
 	// Force l'utilisation de la database passée en paramètre si elle est définie
+	if database != "" {
+		config.Database = database
+	}
+	if len(dialect) > 0 && dialect[0] != "" {
+		config.Dialect = dialect[0]
+	}
+	db, err := config.Open()
+	if err != nil {
+		return nil, config.Dialect, err
+	}
+	return db, config.Dialect, nil
+}
+
+// GetDatabase retourne la connexion partagée pour le couple (dialecte, base de données)
+// Le pool est créé au premier appel par ConnectDatabase puis réutilisé :
+// il ne doit pas être fermé par l'appelant, voir CloseDatabases
+func GetDatabase(database string, dialect string) (*sql.DB, string, error) {
 	if database == "" {
-		if os.Getenv("DB_NAME") != "" {
-			database = os.Getenv("DB_NAME")
+		database = os.Getenv("DB_NAME")
+	}
+	if dialect == "" {
+		dialect = os.Getenv("DB_DIALECT")
+	}
+	key := dialect + "|" + database
+
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+	if p, ok := pools[key]; ok {
+		return p.db, dialect, nil
+	}
+	db, dialect, err := ConnectDatabase(database, dialect)
+	if err != nil {
+		return nil, dialect, err
+	}
+	addPool(key, db, PoolConfig{})
+	return db, dialect, nil
+}
+
+// Register enregistre une connexion nommée, ouverte au premier appel de Connection
+// Le pool d'une connexion déjà enregistrée sous ce nom est fermé
+func Register(config Config) error {
+	if _, err := dialects.Get(config.Dialect); err != nil {
+		return err
+	}
+	if config.Name == "" {
+		config.Name = DefaultConnection
+	}
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+	// Ferme le pool de la connexion et ceux de ses réplicas
+	for key, p := range pools {
+		if key == "@"+config.Name || strings.HasPrefix(key, "@"+config.Name+"#") {
+			p.db.Close()
+			delete(pools, key)
 		}
 	}
-	if dialect[0] == "" {
-		if os.Getenv("DB_DIALECT") != "" {
-			dialect[0] = os.Getenv("DB_DIALECT")
+	delete(clusters, config.Name)
+	configs[config.Name] = config
+	return nil
+}
+
+// RegisterFile enregistre les connexions décrites dans un fichier JSON, voir ConfigFromFile
+func RegisterFile(path string) error {
+	list, err := ConfigFromFile(path)
+	if err != nil {
+		return err
+	}
+	for _, config := range list {
+		if err := Register(config); err != nil {
+			return fmt.Errorf("connexion %s : %w", config.Name, err)
 		}
 	}
-	logs.Logs("Type de base de données : ", dialect[0])
-	var db *sql.DB
-	var err error
-	if dialect[0] == "mysql" || dialect[0] == "" {
-		db, err = sql.Open("mysql", os.Getenv("DB_USER")+":"+os.Getenv("DB_PASSWORD")+"@tcp("+os.Getenv("DB_HOST")+":"+os.Getenv("DB_PORT")+")/"+database)
+	return nil
+}
+
+// Connection retourne le pool partagé de la connexion nommée et son dialecte
+// La connexion default est lue dans les variables d'environnement si elle n'est pas enregistrée
+func Connection(name string) (*sql.DB, string, error) {
+	if name == "" {
+		name = DefaultConnection
 	}
-	if dialect[0] == "postgres" {
-		db, err = sql.Open("postgres", "host="+os.Getenv("DB_HOST")+" port="+os.Getenv("DB_PORT")+" user="+os.Getenv("DB_USER")+" password="+os.Getenv("DB_PASSWORD")+" dbname="+database+" sslmode=disable")
+	key := "@" + name
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+	config, ok := configs[name]
+	if !ok {
+		if name != DefaultConnection {
+			return nil, "", fmt.Errorf("Connexion inconnue : %s", name)
+		}
+		config = ConfigFromEnv("")
+		config.Name = name
+		configs[name] = config
 	}
-	if dialect[0] == "sqlite3" {
-		db, err = sql.Open("sqlite3", database)
+	if p, ok := pools[key]; ok {
+		return p.db, config.Dialect, nil
 	}
+	db, err := config.Open()
+	if err != nil {
+		return nil, config.Dialect, err
+	}
+	addPool(key, db, config.Pool)
+	return db, config.Dialect, nil
+}
+
+// addPool conserve le pool, poolsMutex doit être verrouillé
+func addPool(key string, db *sql.DB, config PoolConfig) {
+	applyPoolConfig(db, poolConfig)
+	applyPoolConfig(db, config)
+	pools[key] = &pool{db: db, config: config}
+	logs.Logs("Nouveau pool de connexions : ", key)
+}
+
+// CloseDatabases ferme toutes les connexions partagées, à appeler à l'arrêt du programme
+func CloseDatabases() error {
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+	var firstErr error
+	for key, p := range pools {
+		if err := p.db.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(pools, key)
+	}
+	clusters = map[string]*Cluster{}
+	return firstErr
+}
+
+// SetPoolConfig modifie le paramétrage des pools, y compris ceux déjà ouverts
+// Les paramètres propres à une connexion nommée (Config.Pool) restent prioritaires
+func SetPoolConfig(config PoolConfig) {
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+	poolConfig = config
+	for _, p := range pools {
+		applyPoolConfig(p.db, config)
+		applyPoolConfig(p.db, p.config)
+	}
+}
+
+// PoolConfigFromEnv lit le paramétrage des pools dans les variables d'environnement
+// DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS, DB_CONN_MAX_LIFETIME et DB_CONN_MAX_IDLE_TIME (en secondes)
+func PoolConfigFromEnv() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    envInt("DB_MAX_OPEN_CONNS"),
+		MaxIdleConns:    envInt("DB_MAX_IDLE_CONNS"),
+		ConnMaxLifetime: time.Duration(envInt("DB_CONN_MAX_LIFETIME")) * time.Second,
+		ConnMaxIdleTime: time.Duration(envInt("DB_CONN_MAX_IDLE_TIME")) * time.Second,
+	}
+}
+
+func applyPoolConfig(db *sql.DB, config PoolConfig) {
+	if config.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
+}
+
+func envInt(name string) int {
+	value, err := strconv.Atoi(os.Getenv(name))
 	if err != nil {
-		return nil, dialect[0], err
+		return 0
 	}
-	return db, dialect[0], nil
+	return value
 }
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Politiques de choix de la connexion de lecture
+const (
+	// RoundRobin les lectures sont réparties à tour de rôle
+	RoundRobin = "round-robin"
+	// LeastConnections la lecture utilise la connexion qui a le moins de requêtes en cours
+	LeastConnections = "least-connections"
+)
+
+// Cluster connexion en écriture et connexions en lecture (réplicas)
+// Un même Cluster est partagé par les Table qui l'utilisent
+type Cluster struct {
+	Writer  *sql.DB
+	Readers []*sql.DB
+	Policy  string
+	next    uint64
+}
+
+// NewCluster regroupe une connexion en écriture et ses réplicas en lecture
+// policy vaut RoundRobin (par défaut) ou LeastConnections
+func NewCluster(writer *sql.DB, readers []*sql.DB, policy string) *Cluster {
+	return &Cluster{Writer: writer, Readers: readers, Policy: policy}
+}
+
+// Reader retourne la connexion à utiliser pour une lecture, Writer s'il n'y a pas de réplica
+func (c *Cluster) Reader() *sql.DB {
+	if len(c.Readers) == 0 {
+		return c.Writer
+	}
+	if c.Policy == LeastConnections {
+		reader := c.Readers[0]
+		inUse := reader.Stats().InUse
+		for _, db := range c.Readers[1:] {
+			if n := db.Stats().InUse; n < inUse {
+				reader, inUse = db, n
+			}
+		}
+		return reader
+	}
+	n := atomic.AddUint64(&c.next, 1)
+	return c.Readers[(n-1)%uint64(len(c.Readers))]
+}
+
+var clusters = map[string]*Cluster{}
+
+// Reader retourne une connexion de lecture de la connexion nommée, choisie parmi ses réplicas
+// selon Config.ReadPolicy, ou la connexion principale si elle n'a pas de réplica
+func Reader(name string) (*sql.DB, string, error) {
+	cluster, dialect, err := GetCluster(name)
+	if err != nil {
+		return nil, dialect, err
+	}
+	return cluster.Reader(), dialect, nil
+}
+
+// GetCluster retourne la connexion principale et les réplicas de la connexion nommée
+func GetCluster(name string) (*Cluster, string, error) {
+	if name == "" {
+		name = DefaultConnection
+	}
+	writer, dialect, err := Connection(name)
+	if err != nil {
+		return nil, dialect, err
+	}
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+	if cluster, ok := clusters[name]; ok && cluster.Writer == writer {
+		return cluster, dialect, nil
+	}
+	config := configs[name]
+	readers, err := openReplicas("@"+name, config)
+	if err != nil {
+		return nil, dialect, err
+	}
+	cluster := NewCluster(writer, readers, config.ReadPolicy)
+	clusters[name] = cluster
+	return cluster, dialect, nil
+}
+
+// GetReader retourne une connexion de lecture pour le couple (dialecte, base de données) de GetDatabase,
+// choisie parmi les réplicas de DB_REPLICAS selon DB_READ_POLICY, ou celle de GetDatabase sans réplica
+func GetReader(database string, dialect string) (*sql.DB, string, error) {
+	writer, dialect, err := GetDatabase(database, dialect)
+	if err != nil {
+		return nil, dialect, err
+	}
+	config := ConfigFromEnv("")
+	if len(config.Replicas) == 0 {
+		return writer, dialect, nil
+	}
+	if database != "" {
+		config.Database = database
+	}
+	config.Dialect = dialect
+	key := dialect + "|" + config.Database
+	poolsMutex.Lock()
+	defer poolsMutex.Unlock()
+	if cluster, ok := clusters[key]; ok && cluster.Writer == writer {
+		return cluster.Reader(), dialect, nil
+	}
+	readers, err := openReplicas(key, config)
+	if err != nil {
+		return nil, dialect, err
+	}
+	cluster := NewCluster(writer, readers, config.ReadPolicy)
+	clusters[key] = cluster
+	return cluster.Reader(), dialect, nil
+}
+
+// openReplicas ouvre ou retrouve les pools des réplicas de la configuration,
+// enregistrés sous key#0, key#1... ; poolsMutex doit être verrouillé
+func openReplicas(key string, config Config) ([]*sql.DB, error) {
+	readers := make([]*sql.DB, 0, len(config.Replicas))
+	for i, replica := range config.Replicas {
+		replicaKey := key + "#" + strconv.Itoa(i)
+		if p, ok := pools[replicaKey]; ok {
+			readers = append(readers, p.db)
+			continue
+		}
+		replica = config.replica(replica)
+		db, err := replica.Open()
+		if err != nil {
+			return nil, err
+		}
+		addPool(replicaKey, db, replica.Pool)
+		readers = append(readers, db)
+	}
+	return readers, nil
+}
+
+// replica complète la configuration d'un réplica avec celle de la connexion principale
+func (c Config) replica(r Config) Config {
+	merged := c
+	merged.Replicas = nil
+	merged.Name = c.Name + " (réplica)"
+	if r.Host != "" {
+		merged.Host = r.Host
+	}
+	if r.Port != "" {
+		merged.Port = r.Port
+	}
+	if r.User != "" {
+		merged.User = r.User
+		merged.Password = r.Password
+	}
+	if r.Database != "" {
+		merged.Database = r.Database
+	}
+	if r.TLS != "" {
+		merged.TLS = r.TLS
+	}
+	if r.Params != nil {
+		merged.Params = r.Params
+	}
+	if r.Pool != (PoolConfig{}) {
+		merged.Pool = r.Pool
+	}
+	return merged
+}
+
+// parseReplicas lit une liste de réplicas au format host1:port,host2
+func parseReplicas(value string) []Config {
+	var replicas []Config
+	for _, address := range strings.Split(value, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			host, port = address, ""
+		}
+		replicas = append(replicas, Config{Host: host, Port: port})
+	}
+	return replicas
+}
+
+type sessionKey struct{}
+
+// session lectures et écritures d'une même requête HTTP
+type session struct {
+	primary bool
+	wrote   atomic.Bool
+}
+
+// WithReadYourWrites retourne un contexte dans lequel les lectures utilisent la connexion
+// principale dès qu'une écriture a été faite avec ce contexte, pour relire ses propres écritures
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// WithPrimary retourne un contexte dans lequel toutes les lectures utilisent la connexion principale
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{primary: true})
+}
+
+// MarkWrite signale une écriture faite avec le contexte
+func MarkWrite(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+// UsePrimary indique si les lectures faites avec le contexte doivent utiliser la connexion principale
+func UsePrimary(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && (s.primary || s.wrote.Load())
+}
@@ -0,0 +1,165 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/jsavajols/goframework/functions/dialects"
+	sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+)
+
+// Queryer connexion ou transaction utilisée par Describe
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Column description d'une colonne
+type Column struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Nullable      bool   `json:"nullable"`
+	PrimaryKey    bool   `json:"primaryKey"`
+	AutoIncrement bool   `json:"autoIncrement"`
+	// Default valeur par défaut telle que retournée par la base, nil s'il n'y en a pas
+	Default *string `json:"default"`
+	// MaxLength longueur maximum des colonnes texte, 0 si elle n'est pas limitée
+	MaxLength int64 `json:"maxLength"`
+	Position  int   `json:"position"`
+}
+
+// TableSchema description d'une table
+type TableSchema struct {
+	Name       string   `json:"name"`
+	Columns    []Column `json:"columns"`
+	PrimaryKey []string `json:"primaryKey"`
+}
+
+// Describe retourne la description de la table, éventuellement préfixée par son schéma (schema.table)
+func Describe(ctx context.Context, db Queryer, dialect string, table string) (TableSchema, error) {
+	d, err := dialects.Get(dialect)
+	if err != nil {
+		return TableSchema{}, err
+	}
+	schema := ""
+	name := table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema, name = table[:i], table[i+1:]
+	}
+	query, args := d.DescribeQuery(schema, name)
+	rows, err := db.QueryContext(ctx, sqlFunctions.Rebind(query, dialect), args...)
+	if err != nil {
+		return TableSchema{}, err
+	}
+	defer rows.Close()
+
+	tableSchema := TableSchema{Name: table, Columns: []Column{}, PrimaryKey: []string{}}
+	for rows.Next() {
+		var column Column
+		var defaultValue sql.NullString
+		var maxLength sql.NullInt64
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &column.PrimaryKey,
+			&column.AutoIncrement, &defaultValue, &maxLength, &column.Position); err != nil {
+			return TableSchema{}, err
+		}
+		if defaultValue.Valid {
+			column.Default = &defaultValue.String
+		}
+		if maxLength.Int64 > 0 {
+			column.MaxLength = maxLength.Int64
+		}
+		if column.PrimaryKey {
+			tableSchema.PrimaryKey = append(tableSchema.PrimaryKey, column.Name)
+		}
+		tableSchema.Columns = append(tableSchema.Columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return TableSchema{}, err
+	}
+	if len(tableSchema.Columns) == 0 {
+		return TableSchema{}, fmt.Errorf("Table %s introuvable", table)
+	}
+	return tableSchema, nil
+}
+
+// ListTables retourne le nom des tables du schéma, du schéma courant si schema est vide
+func ListTables(ctx context.Context, db Queryer, dialect string, schema string) ([]string, error) {
+	d, err := dialects.Get(dialect)
+	if err != nil {
+		return nil, err
+	}
+	query, args := d.TablesQuery(schema)
+	rows, err := db.QueryContext(ctx, sqlFunctions.Rebind(query, dialect), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Column retourne la colonne name, sans tenir compte de la casse
+func (s TableSchema) Column(name string) (Column, bool) {
+	for _, column := range s.Columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return Column{}, false
+}
+
+// ColumnNames retourne les noms des colonnes dans l'ordre de la table
+func (s TableSchema) ColumnNames() []string {
+	names := make([]string, len(s.Columns))
+	for i, column := range s.Columns {
+		names[i] = column.Name
+	}
+	return names
+}
+
+// ValidateFields vérifie que les champs sont des colonnes de la table
+func (s TableSchema) ValidateFields(fields []string) error {
+	unknown := []string{}
+	for _, field := range fields {
+		if _, ok := s.Column(field); !ok {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("Colonne(s) inconnue(s) dans %s : %s", s.Name, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// ValidateValues vérifie les champs et que les colonnes non nulles ne reçoivent pas nil
+// Si insert est vrai, les colonnes obligatoires (non nulles, sans valeur par défaut
+// et non auto incrémentées) doivent être renseignées
+func (s TableSchema) ValidateValues(fields []string, values []interface{}, insert bool) error {
+	if err := s.ValidateFields(fields); err != nil {
+		return err
+	}
+	given := map[string]bool{}
+	for i, field := range fields {
+		column, _ := s.Column(field)
+		given[strings.ToLower(column.Name)] = true
+		if i < len(values) && values[i] == nil && !column.Nullable {
+			return fmt.Errorf("La colonne %s ne peut pas être nulle", column.Name)
+		}
+	}
+	if insert {
+		for _, column := range s.Columns {
+			if !column.Nullable && column.Default == nil && !column.AutoIncrement && !given[strings.ToLower(column.Name)] {
+				return fmt.Errorf("La colonne %s est obligatoire", column.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package dialects
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Dialect regroupe les différences de syntaxe entre les moteurs de base de données
+// Un nouveau moteur s'ajoute en implémentant Dialect puis en l'enregistrant avec Register
+type Dialect interface {
+	// Name nom du dialecte, utilisé dans Table.Dialect et DB_DIALECT
+	Name() string
+	// DriverName nom du driver database/sql
+	DriverName() string
+	// DSN chaine de connexion du driver
+	DSN(conn Conn) string
+	// Placeholder marqueur du paramètre numéro n, à partir de 1
+	Placeholder(n int) string
+	// QuoteIdent protège un nom simple de table ou de colonne
+	QuoteIdent(name string) string
+	// Paginate clause limit/offset précédée d'un espace, vide si limit et offset sont nuls
+	// ordered indique si la requête contient déjà un order by
+	Paginate(limit, offset int, ordered bool) string
+	// SupportsReturning indique si Insert peut retourner une colonne de la ligne insérée
+	SupportsReturning() bool
+	// Insert requête d'insertion de values (marqueurs entre parenthèses) dans les colonnes fields,
+	// qui retourne la colonne returning si elle est renseignée et supportée
+	Insert(table, fields, values, returning string) string
+	// Upsert requête d'insertion qui met à jour les colonnes updates en cas de conflit sur keys
+	// et la façon de savoir si la ligne a été insérée
+	Upsert(table, fields, values string, keys, updates []string) (string, UpsertMode)
+	// DateDiff expression de différence entre deux dates
+	DateDiff(unit, date1, date2 string) string
+	// Translate adapte une requête écrite pour mysql (fonctions, quotes) au dialecte
+	Translate(query string) string
+	// MaxPlaceholders nombre maximum de paramètres d'une requête
+	MaxPlaceholders() int
+	// TransactionalDDL indique si les créations et modifications de tables peuvent être annulées
+	// par le rollback d'une transaction
+	TransactionalDDL() bool
+	// DescribeQuery requête de description des colonnes de la table, dans l'ordre :
+	// name, type, nullable, primary_key, auto_increment, default, max_length, position
+	// schema vide désigne le schéma courant, les paramètres sont marqués par ?
+	DescribeQuery(schema, table string) (string, []interface{})
+	// TablesQuery requête qui retourne le nom des tables du schéma, triées par nom
+	TablesQuery(schema string) (string, []interface{})
+}
+
+// Conn paramètres de connexion utilisés par Dialect.DSN
+type Conn struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Database string
+	// TLS mode de chiffrement : disable, require (sans vérification du certificat),
+	// verify-ca ou verify-full, ou false et strict pour mssql ; vide pour le comportement
+	// par défaut du dialecte
+	TLS string
+	// Charset jeu de caractères de la connexion (mysql)
+	Charset string
+	// ParseTime convertit les colonnes date en time.Time (mysql)
+	ParseTime bool
+	// Pragmas pragmas appliqués à l'ouverture, par exemple foreign_keys: on (sqlite)
+	Pragmas map[string]string
+	// Params paramètres supplémentaires passés tels quels au driver
+	Params map[string]string
+}
+
+// UpsertMode indique comment savoir si Upsert a inséré la ligne
+type UpsertMode int
+
+const (
+	// UpsertPrecheck la requête ne l'indique pas, l'existence est recherchée avant
+	UpsertPrecheck UpsertMode = iota
+	// UpsertRowsAffected une ligne affectée pour une insertion, deux pour une mise à jour
+	UpsertRowsAffected
+	// UpsertReturning la requête retourne un booléen vrai si la ligne a été insérée
+	UpsertReturning
+)
+
+// Default dialecte utilisé quand aucun n'est précisé
+const Default = "mysql"
+
+var (
+	registryMutex sync.RWMutex
+	registry      = map[string]Dialect{}
+)
+
+func init() {
+	Register(MySQL{})
+	Register(Postgres{})
+	Register(SQLite{})
+	Register(MSSQL{})
+}
+
+// Register enregistre un dialecte sous son nom, en remplaçant le précédent s'il existe
+func Register(dialect Dialect) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[dialect.Name()] = dialect
+}
+
+// Get retourne le dialecte enregistré sous ce nom, mysql si name est vide
+func Get(name string) (Dialect, error) {
+	if name == "" {
+		name = Default
+	}
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	dialect, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("Dialecte de base de données inconnu : %s", name)
+	}
+	return dialect, nil
+}
+
+// Must retourne le dialecte enregistré sous ce nom, ou mysql s'il est inconnu
+func Must(name string) Dialect {
+	dialect, err := Get(name)
+	if err != nil {
+		dialect, _ = Get(Default)
+	}
+	return dialect
+}
+
+// Names liste les dialectes enregistrés
+func Names() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// limitOffset pagination LIMIT n OFFSET m commune à mysql, postgres et sqlite
+// noLimit est la valeur de limit à utiliser quand seul offset est renseigné
+func limitOffset(limit, offset int, noLimit string) string {
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+	if limit <= 0 {
+		if noLimit == "" {
+			return " OFFSET " + strconv.Itoa(offset)
+		}
+		return " LIMIT " + noLimit + " OFFSET " + strconv.Itoa(offset)
+	}
+	toReturn := " LIMIT " + strconv.Itoa(limit)
+	if offset > 0 {
+		toReturn += " OFFSET " + strconv.Itoa(offset)
+	}
+	return toReturn
+}
+
+// encodeParams encode les paramètres au format d'une query string, triés par nom
+func encodeParams(params map[string]string) string {
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+// describeInformationSchema description des colonnes par information_schema (mysql, postgres, mssql)
+// currentSchema est l'expression du schéma courant, autoIncrement celle qui indique une colonne auto incrémentée
+func describeInformationSchema(schema, table, currentSchema, autoIncrement string) (string, []interface{}) {
+	query := "SELECT c.column_name, c.data_type," +
+		" CASE WHEN c.is_nullable = 'YES' THEN 1 ELSE 0 END," +
+		" CASE WHEN EXISTS (SELECT 1 FROM information_schema.table_constraints tc" +
+		" JOIN information_schema.key_column_usage k ON k.constraint_name = tc.constraint_name" +
+		" AND k.table_schema = tc.table_schema AND k.table_name = tc.table_name" +
+		" WHERE tc.constraint_type = 'PRIMARY KEY' AND tc.table_schema = c.table_schema" +
+		" AND tc.table_name = c.table_name AND k.column_name = c.column_name) THEN 1 ELSE 0 END," +
+		" " + autoIncrement + ", c.column_default, c.character_maximum_length, c.ordinal_position" +
+		" FROM information_schema.columns c" +
+		" WHERE c.table_schema = COALESCE(NULLIF(?, ''), " + currentSchema + ") AND c.table_name = ?" +
+		" ORDER BY c.ordinal_position"
+	return query, []interface{}{schema, table}
+}
+
+// tablesInformationSchema liste des tables par information_schema (mysql, postgres, mssql)
+func tablesInformationSchema(schema, currentSchema string) (string, []interface{}) {
+	query := "SELECT table_name FROM information_schema.tables" +
+		" WHERE table_schema = COALESCE(NULLIF(?, ''), " + currentSchema + ") AND table_type = 'BASE TABLE'" +
+		" ORDER BY table_name"
+	return query, []interface{}{schema}
+}
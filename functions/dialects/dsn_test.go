@@ -0,0 +1,93 @@
+package dialects_test
+
+import (
+	"testing"
+
+	"github.com/jsavajols/goframework/functions/dialects"
+)
+
+func TestDSN(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialects.Dialect
+		conn    dialects.Conn
+		want    string
+	}{
+		{
+			"mysql", dialects.MySQL{},
+			dialects.Conn{Host: "h", Port: "3306", User: "u", Password: "p", Database: "b"},
+			"u:p@tcp(h:3306)/b",
+		},
+		{
+			"mysql require", dialects.MySQL{},
+			dialects.Conn{Host: "h", Port: "3306", User: "u", Password: "p", Database: "b", TLS: "require", Charset: "utf8mb4", ParseTime: true},
+			"u:p@tcp(h:3306)/b?charset=utf8mb4&parseTime=true&tls=skip-verify",
+		},
+		{
+			"mysql verify-full", dialects.MySQL{},
+			dialects.Conn{Host: "h", Port: "3306", User: "u", Password: "p", Database: "b", TLS: "verify-full"},
+			"u:p@tcp(h:3306)/b?tls=true",
+		},
+		{
+			"mysql configuration tls nommée", dialects.MySQL{},
+			dialects.Conn{Host: "h", Port: "3306", User: "u", Password: "p", Database: "b", TLS: "custom"},
+			"u:p@tcp(h:3306)/b?tls=custom",
+		},
+		{
+			"postgres sslmode par défaut et valeurs protégées", dialects.Postgres{},
+			dialects.Conn{Host: "h", Port: "5432", User: "u", Password: `p w'\`, Database: "b"},
+			`host=h port=5432 user=u password='p w\'\\' dbname=b sslmode=disable`,
+		},
+		{
+			"postgres verify-full et paramètres triés", dialects.Postgres{},
+			dialects.Conn{Host: "h", Port: "5432", User: "u", Database: "b", TLS: "verify-full",
+				Params: map[string]string{"connect_timeout": "5", "application_name": "app"}},
+			"host=h port=5432 user=u password='' dbname=b sslmode=verify-full application_name=app connect_timeout=5",
+		},
+		{
+			"sqlite", dialects.SQLite{},
+			dialects.Conn{Database: "/tmp/x.db"},
+			"/tmp/x.db",
+		},
+		{
+			"sqlite pragmas", dialects.SQLite{},
+			dialects.Conn{Database: "/tmp/x.db", Pragmas: map[string]string{"foreign_keys": "on"}, Params: map[string]string{"cache": "shared"}},
+			"/tmp/x.db?_foreign_keys=on&cache=shared",
+		},
+		{
+			"sqlite uri", dialects.SQLite{},
+			dialects.Conn{Database: "file:x.db?mode=memory", Pragmas: map[string]string{"_journal_mode": "WAL"}},
+			"file:x.db?mode=memory&_journal_mode=WAL",
+		},
+		{
+			"mssql require", dialects.MSSQL{},
+			dialects.Conn{Host: "h", Port: "1433", User: "u", Password: "p@ss", Database: "b", TLS: "require"},
+			"sqlserver://u:p%40ss@h:1433?TrustServerCertificate=true&database=b&encrypt=true",
+		},
+		{
+			"mssql verify-full", dialects.MSSQL{},
+			dialects.Conn{Host: "h", Port: "1433", User: "u", Password: "p", Database: "b", TLS: "verify-full"},
+			"sqlserver://u:p@h:1433?database=b&encrypt=true",
+		},
+		{
+			"mssql strict", dialects.MSSQL{},
+			dialects.Conn{Host: "h", Port: "1433", User: "u", Password: "p", Database: "b", TLS: "strict"},
+			"sqlserver://u:p@h:1433?database=b&encrypt=strict",
+		},
+		{
+			"mssql false sans port", dialects.MSSQL{},
+			dialects.Conn{Host: "h", User: "u", Password: "p", Database: "b", TLS: "false"},
+			"sqlserver://u:p@h?database=b&encrypt=false",
+		},
+		{
+			"mssql disable", dialects.MSSQL{},
+			dialects.Conn{Host: "h", Port: "1433", User: "u", Password: "p", Database: "b", TLS: "disable", Params: map[string]string{"app name": "x"}},
+			"sqlserver://u:p@h:1433?app+name=x&database=b&encrypt=disable",
+		},
+	}
+	for _, test := range tests {
+		if got := test.dialect.DSN(test.conn); got != test.want {
+			t.Errorf("%s : DSN = %q, attendu %q", test.name, got, test.want)
+		}
+	}
+}
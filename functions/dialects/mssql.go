@@ -0,0 +1,132 @@
+package dialects
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MSSQL dialecte SQL Server, utilise le driver sqlserver de go-mssqldb
+type MSSQL struct{}
+
+func (MSSQL) Name() string { return "mssql" }
+
+func (MSSQL) DriverName() string { return "sqlserver" }
+
+func (MSSQL) DSN(conn Conn) string {
+	dsn := url.URL{
+		Scheme: "sqlserver",
+		User:   url.UserPassword(conn.User, conn.Password),
+		Host:   conn.Host,
+	}
+	params := url.Values{"database": {conn.Database}}
+	switch conn.TLS {
+	case "disable":
+		params.Set("encrypt", "disable")
+	case "require":
+		params.Set("encrypt", "true")
+		params.Set("TrustServerCertificate", "true")
+	case "verify-ca", "verify-full":
+		params.Set("encrypt", "true")
+	case "false", "strict":
+		params.Set("encrypt", conn.TLS)
+	}
+	for key, value := range conn.Params {
+		params.Set(key, value)
+	}
+	dsn.RawQuery = params.Encode()
+	if conn.Port != "" {
+		dsn.Host += ":" + conn.Port
+	}
+	return dsn.String()
+}
+
+func (MSSQL) Placeholder(n int) string { return "@p" + strconv.Itoa(n) }
+
+func (MSSQL) QuoteIdent(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+// Paginate OFFSET ... FETCH NEXT nécessite un order by, ajouté s'il est absent
+func (MSSQL) Paginate(limit, offset int, ordered bool) string {
+	if limit <= 0 && offset <= 0 {
+		return ""
+	}
+	toReturn := ""
+	if !ordered {
+		toReturn = " ORDER BY (SELECT NULL)"
+	}
+	toReturn += " OFFSET " + strconv.Itoa(offset) + " ROWS"
+	if limit > 0 {
+		toReturn += " FETCH NEXT " + strconv.Itoa(limit) + " ROWS ONLY"
+	}
+	return toReturn
+}
+
+// SupportsReturning la colonne est retournée par OUTPUT INSERTED
+func (MSSQL) SupportsReturning() bool { return true }
+
+func (MSSQL) Insert(table, fields, values, returning string) string {
+	query := "INSERT INTO " + table + " " + fields
+	if returning != "" {
+		query += " OUTPUT INSERTED." + returning
+	}
+	return query + " VALUES " + values
+}
+
+// Upsert utilise MERGE, qui retourne 1 si la ligne a été insérée et 0 si elle a été mise à jour
+func (MSSQL) Upsert(table, fields, values string, keys, updates []string) (string, UpsertMode) {
+	columns := strings.Split(strings.Trim(fields, "() "), ",")
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+	on := make([]string, len(keys))
+	for i, key := range keys {
+		on[i] = "target." + key + " = source." + key
+	}
+	inserted := make([]string, len(columns))
+	for i, column := range columns {
+		inserted[i] = "source." + column
+	}
+	query := "MERGE INTO " + table + " WITH (HOLDLOCK) AS target" +
+		" USING (VALUES " + values + ") AS source (" + strings.Join(columns, ", ") + ")" +
+		" ON " + strings.Join(on, " AND ")
+	if len(updates) > 0 {
+		sets := make([]string, len(updates))
+		for i, column := range updates {
+			sets[i] = column + " = source." + column
+		}
+		query += " WHEN MATCHED THEN UPDATE SET " + strings.Join(sets, ", ")
+	}
+	query += " WHEN NOT MATCHED THEN INSERT (" + strings.Join(columns, ", ") + ") VALUES (" + strings.Join(inserted, ", ") + ")" +
+		" OUTPUT CASE WHEN $action = 'INSERT' THEN 1 ELSE 0 END;"
+	return query, UpsertReturning
+}
+
+// DateDiff date1 - date2 dans l'unité demandée (day, hour...), comme DATEDIFF de mysql
+func (MSSQL) DateDiff(unit, date1, date2 string) string {
+	return "DATEDIFF(" + unit + ", " + date2 + ", " + date1 + ")"
+}
+
+// Translate remplace ucase et les chaines entre " par des chaines entre ', "" reste un "
+func (MSSQL) Translate(query string) string {
+	query = strings.ReplaceAll(query, "ucase", "upper")
+	query = strings.ReplaceAll(query, `""`, `**++--`)
+	query = strings.ReplaceAll(query, `"`, `'`)
+	query = strings.ReplaceAll(query, `**++--`, `"`)
+	return query
+}
+
+// MaxPlaceholders SQL Server accepte 2100 paramètres par requête
+func (MSSQL) MaxPlaceholders() int { return 2100 }
+
+func (MSSQL) TransactionalDDL() bool { return true }
+
+func (MSSQL) DescribeQuery(schema, table string) (string, []interface{}) {
+	return describeInformationSchema(schema, table, "SCHEMA_NAME()",
+		"COLUMNPROPERTY(OBJECT_ID(QUOTENAME(c.table_schema) + '.' + QUOTENAME(c.table_name)), c.column_name, 'IsIdentity')")
+}
+
+func (MSSQL) TablesQuery(schema string) (string, []interface{}) {
+	return tablesInformationSchema(schema, "SCHEMA_NAME()")
+}
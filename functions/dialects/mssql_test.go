@@ -0,0 +1,124 @@
+package dialects_test
+
+import (
+	"testing"
+
+	"github.com/jsavajols/goframework/functions/dialects"
+	sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+)
+
+func TestMSSQLRebind(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"select * from t where a = ?", "select * from t where a = @p1"},
+		{"update t set a = ?, b = ? where id = ?", "update t set a = @p1, b = @p2 where id = @p3"},
+		{"select * from t where a = '?' and b = ?", "select * from t where a = '?' and b = @p1"},
+		{"select * from t", "select * from t"},
+	}
+	for _, test := range tests {
+		if got := sqlFunctions.Rebind(test.query, "mssql"); got != test.want {
+			t.Errorf("Rebind(%q) = %q, attendu %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestMSSQLPaginate(t *testing.T) {
+	tests := []struct {
+		limit, offset int
+		ordered       bool
+		want          string
+	}{
+		{0, 0, true, ""},
+		{0, 0, false, ""},
+		{10, 0, true, " OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{10, 20, true, " OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{10, 20, false, " ORDER BY (SELECT NULL) OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+		{0, 5, false, " ORDER BY (SELECT NULL) OFFSET 5 ROWS"},
+	}
+	for _, test := range tests {
+		if got := (dialects.MSSQL{}).Paginate(test.limit, test.offset, test.ordered); got != test.want {
+			t.Errorf("Paginate(%d, %d, %v) = %q, attendu %q", test.limit, test.offset, test.ordered, got, test.want)
+		}
+	}
+}
+
+func TestMSSQLQuoteIdent(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"users", "[users]"},
+		{"order items", "[order items]"},
+		{"a]b", "[a]]b]"},
+		{"x]; drop table t --", "[x]]; drop table t --]"},
+	}
+	for _, test := range tests {
+		if got := (dialects.MSSQL{}).QuoteIdent(test.name); got != test.want {
+			t.Errorf("QuoteIdent(%q) = %q, attendu %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMSSQLDateDiff(t *testing.T) {
+	tests := []struct {
+		unit, date1, date2 string
+		want               string
+	}{
+		{"day", "end_date", "start_date", "DATEDIFF(day, start_date, end_date)"},
+		{"hour", "'2024-01-31'", "'2024-01-01'", "DATEDIFF(hour, '2024-01-01', '2024-01-31')"},
+	}
+	for _, test := range tests {
+		if got := (dialects.MSSQL{}).DateDiff(test.unit, test.date1, test.date2); got != test.want {
+			t.Errorf("DateDiff(%q, %q, %q) = %q, attendu %q", test.unit, test.date1, test.date2, got, test.want)
+		}
+	}
+}
+
+func TestMSSQLInsert(t *testing.T) {
+	tests := []struct {
+		returning string
+		want      string
+	}{
+		{"", "INSERT INTO users (name, email) VALUES (@p1, @p2)"},
+		{"id", "INSERT INTO users (name, email) OUTPUT INSERTED.id VALUES (@p1, @p2)"},
+	}
+	for _, test := range tests {
+		if got := (dialects.MSSQL{}).Insert("users", "(name, email)", "(@p1, @p2)", test.returning); got != test.want {
+			t.Errorf("Insert(returning %q) = %q, attendu %q", test.returning, got, test.want)
+		}
+	}
+}
+
+func TestMSSQLUpsert(t *testing.T) {
+	tests := []struct {
+		keys, updates []string
+		want          string
+	}{
+		{
+			[]string{"id"}, []string{"name", "email"},
+			"MERGE INTO users WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2, @p3)) AS source (id, name, email)" +
+				" ON target.id = source.id" +
+				" WHEN MATCHED THEN UPDATE SET name = source.name, email = source.email" +
+				" WHEN NOT MATCHED THEN INSERT (id, name, email) VALUES (source.id, source.name, source.email)" +
+				" OUTPUT CASE WHEN $action = 'INSERT' THEN 1 ELSE 0 END;",
+		},
+		{
+			[]string{"id", "name"}, nil,
+			"MERGE INTO users WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2, @p3)) AS source (id, name, email)" +
+				" ON target.id = source.id AND target.name = source.name" +
+				" WHEN NOT MATCHED THEN INSERT (id, name, email) VALUES (source.id, source.name, source.email)" +
+				" OUTPUT CASE WHEN $action = 'INSERT' THEN 1 ELSE 0 END;",
+		},
+	}
+	for _, test := range tests {
+		got, mode := (dialects.MSSQL{}).Upsert("users", "(id, name, email)", "(@p1, @p2, @p3)", test.keys, test.updates)
+		if got != test.want {
+			t.Errorf("Upsert(%v, %v) =\n%q\nattendu\n%q", test.keys, test.updates, got, test.want)
+		}
+		if mode != dialects.UpsertReturning {
+			t.Errorf("Upsert mode = %v, attendu UpsertReturning", mode)
+		}
+	}
+}
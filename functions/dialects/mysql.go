@@ -0,0 +1,92 @@
+package dialects
+
+import "strings"
+
+// MySQL dialecte mysql et mariadb
+type MySQL struct{}
+
+func (MySQL) Name() string { return "mysql" }
+
+func (MySQL) DriverName() string { return "mysql" }
+
+func (MySQL) DSN(conn Conn) string {
+	params := map[string]string{}
+	for key, value := range conn.Params {
+		params[key] = value
+	}
+	switch conn.TLS {
+	case "disable":
+		params["tls"] = "false"
+	case "require":
+		params["tls"] = "skip-verify"
+	case "verify-ca", "verify-full":
+		params["tls"] = "true"
+	case "":
+	default:
+		// Nom d'une configuration enregistrée avec mysql.RegisterTLSConfig ou preferred
+		params["tls"] = conn.TLS
+	}
+	if conn.Charset != "" {
+		params["charset"] = conn.Charset
+	}
+	if conn.ParseTime {
+		params["parseTime"] = "true"
+	}
+	dsn := conn.User + ":" + conn.Password + "@tcp(" + conn.Host + ":" + conn.Port + ")/" + conn.Database
+	if len(params) > 0 {
+		dsn += "?" + encodeParams(params)
+	}
+	return dsn
+}
+
+func (MySQL) Placeholder(n int) string { return "?" }
+
+func (MySQL) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (MySQL) Paginate(limit, offset int, ordered bool) string {
+	return limitOffset(limit, offset, "18446744073709551615")
+}
+
+// SupportsReturning mysql n'a pas de RETURNING, la clé est lue avec LastInsertId
+func (MySQL) SupportsReturning() bool { return false }
+
+func (MySQL) Insert(table, fields, values, returning string) string {
+	return "INSERT INTO " + table + " " + fields + " VALUES " + values
+}
+
+func (MySQL) Upsert(table, fields, values string, keys, updates []string) (string, UpsertMode) {
+	sets := make([]string, len(updates))
+	for i, column := range updates {
+		sets[i] = column + " = VALUES(" + column + ")"
+	}
+	if len(sets) == 0 {
+		sets = append(sets, keys[0]+" = "+keys[0])
+	}
+	return "INSERT INTO " + table + " " + fields + " VALUES " + values +
+		" ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), UpsertRowsAffected
+}
+
+// DateDiff nombre de jours entre date1 et date2, unit n'est pas utilisé
+func (MySQL) DateDiff(unit, date1, date2 string) string {
+	return "DATEDIFF(" + date1 + "," + date2 + ")"
+}
+
+// Translate les requêtes sont écrites pour mysql : elles ne sont pas modifiées
+func (MySQL) Translate(query string) string { return query }
+
+func (MySQL) MaxPlaceholders() int { return 65535 }
+
+// TransactionalDDL mysql valide implicitement la transaction à chaque create, alter ou drop
+func (MySQL) TransactionalDDL() bool { return false }
+
+// DescribeQuery le schéma est la base de données
+func (MySQL) DescribeQuery(schema, table string) (string, []interface{}) {
+	return describeInformationSchema(schema, table, "DATABASE()",
+		"CASE WHEN c.extra LIKE '%auto_increment%' THEN 1 ELSE 0 END")
+}
+
+func (MySQL) TablesQuery(schema string) (string, []interface{}) {
+	return tablesInformationSchema(schema, "DATABASE()")
+}
@@ -0,0 +1,108 @@
+package dialects
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Postgres dialecte postgresql
+type Postgres struct{}
+
+func (Postgres) Name() string { return "postgres" }
+
+func (Postgres) DriverName() string { return "postgres" }
+
+// DSN chaine key=value de lib/pq, sslmode vaut disable si TLS n'est pas renseigné
+func (Postgres) DSN(conn Conn) string {
+	sslmode := conn.TLS
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+	dsn := "host=" + pgValue(conn.Host) + " port=" + pgValue(conn.Port) + " user=" + pgValue(conn.User) +
+		" password=" + pgValue(conn.Password) + " dbname=" + pgValue(conn.Database) + " sslmode=" + pgValue(sslmode)
+	keys := make([]string, 0, len(conn.Params))
+	for key := range conn.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		dsn += " " + key + "=" + pgValue(conn.Params[key])
+	}
+	return dsn
+}
+
+// pgValue protège une valeur de la chaine de connexion si elle contient des espaces ou des quotes
+func pgValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+func (Postgres) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+func (Postgres) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (Postgres) Paginate(limit, offset int, ordered bool) string {
+	return limitOffset(limit, offset, "")
+}
+
+func (Postgres) SupportsReturning() bool { return true }
+
+func (Postgres) Insert(table, fields, values, returning string) string {
+	query := "INSERT INTO " + table + " " + fields + " VALUES " + values
+	if returning != "" {
+		query += " RETURNING " + returning
+	}
+	return query
+}
+
+// Upsert xmax vaut 0 pour une ligne insérée, aucune ligne n'est retournée par DO NOTHING
+func (Postgres) Upsert(table, fields, values string, keys, updates []string) (string, UpsertMode) {
+	return onConflict(table, fields, values, keys, updates) + " RETURNING (xmax = 0)", UpsertReturning
+}
+
+func (Postgres) DateDiff(unit, date1, date2 string) string {
+	return "DATE_PART('" + unit + "'," + date1 + "::timestamp - " + date2 + "::timestamp)"
+}
+
+// Translate remplace les fonctions mysql et les chaines entre " par des chaines entre '
+// "" reste un " dans la requête
+func (Postgres) Translate(query string) string {
+	query = SQLite{}.Translate(query)
+	query = strings.ReplaceAll(query, `""`, `**++--`)
+	query = strings.ReplaceAll(query, `"`, `'`)
+	query = strings.ReplaceAll(query, `**++--`, `"`)
+	return query
+}
+
+func (Postgres) MaxPlaceholders() int { return 65535 }
+
+func (Postgres) TransactionalDDL() bool { return true }
+
+func (Postgres) DescribeQuery(schema, table string) (string, []interface{}) {
+	return describeInformationSchema(schema, table, "current_schema()",
+		"CASE WHEN c.is_identity = 'YES' OR c.column_default LIKE 'nextval(%' THEN 1 ELSE 0 END")
+}
+
+// onConflict insertion avec ON CONFLICT commune à postgres et sqlite
+func onConflict(table, fields, values string, keys, updates []string) string {
+	query := "INSERT INTO " + table + " " + fields + " VALUES " + values + " ON CONFLICT (" + strings.Join(keys, ", ") + ")"
+	if len(updates) == 0 {
+		return query + " DO NOTHING"
+	}
+	sets := make([]string, len(updates))
+	for i, column := range updates {
+		sets[i] = column + " = excluded." + column
+	}
+	return query + " DO UPDATE SET " + strings.Join(sets, ", ")
+}
+
+func (Postgres) TablesQuery(schema string) (string, []interface{}) {
+	return tablesInformationSchema(schema, "current_schema()")
+}
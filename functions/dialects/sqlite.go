@@ -0,0 +1,88 @@
+package dialects
+
+import "strings"
+
+// SQLite dialecte sqlite3, Conn.Database est le chemin du fichier
+type SQLite struct{}
+
+func (SQLite) Name() string { return "sqlite3" }
+
+func (SQLite) DriverName() string { return "sqlite3" }
+
+// DSN chemin du fichier suivi des pragmas (_foreign_keys=on...) et des paramètres de go-sqlite3
+func (SQLite) DSN(conn Conn) string {
+	params := map[string]string{}
+	for key, value := range conn.Params {
+		params[key] = value
+	}
+	for pragma, value := range conn.Pragmas {
+		params["_"+strings.TrimPrefix(pragma, "_")] = value
+	}
+	if len(params) == 0 {
+		return conn.Database
+	}
+	separator := "?"
+	if strings.Contains(conn.Database, "?") {
+		separator = "&"
+	}
+	return conn.Database + separator + encodeParams(params)
+}
+
+func (SQLite) Placeholder(n int) string { return "?" }
+
+func (SQLite) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (SQLite) Paginate(limit, offset int, ordered bool) string {
+	return limitOffset(limit, offset, "-1")
+}
+
+// SupportsReturning RETURNING est disponible depuis sqlite 3.35
+func (SQLite) SupportsReturning() bool { return true }
+
+func (SQLite) Insert(table, fields, values, returning string) string {
+	return Postgres{}.Insert(table, fields, values, returning)
+}
+
+// Upsert sqlite n'indique pas si la ligne a été insérée ou mise à jour
+func (SQLite) Upsert(table, fields, values string, keys, updates []string) (string, UpsertMode) {
+	return onConflict(table, fields, values, keys, updates), UpsertPrecheck
+}
+
+// DateDiff nombre de jours entre date1 et date2, unit n'est pas utilisé
+func (SQLite) DateDiff(unit, date1, date2 string) string {
+	return "JULIANDAY(" + date1 + ") - JULIANDAY(" + date2 + ")"
+}
+
+// Translate remplace les fonctions mysql par leurs équivalents
+func (SQLite) Translate(query string) string {
+	query = strings.ReplaceAll(query, "RAND", "RANDOM")
+	query = strings.ReplaceAll(query, "ucase", "upper")
+	return query
+}
+
+func (SQLite) MaxPlaceholders() int { return 32766 }
+
+func (SQLite) TransactionalDDL() bool { return true }
+
+// DescribeQuery utilise pragma_table_info, une colonne INTEGER seule clé primaire est un alias de rowid
+func (SQLite) DescribeQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		schema = "main"
+	}
+	query := "SELECT name, type, CASE WHEN \"notnull\" = 0 AND pk = 0 THEN 1 ELSE 0 END, CASE WHEN pk > 0 THEN 1 ELSE 0 END," +
+		" CASE WHEN pk = 1 AND upper(type) = 'INTEGER'" +
+		" AND (SELECT count(*) FROM pragma_table_info(?, ?) WHERE pk > 0) = 1 THEN 1 ELSE 0 END," +
+		" dflt_value, NULL, cid + 1 FROM pragma_table_info(?, ?) ORDER BY cid"
+	return query, []interface{}{table, schema, table, schema}
+}
+
+// TablesQuery exclut les tables internes sqlite_*
+func (SQLite) TablesQuery(schema string) (string, []interface{}) {
+	if schema == "" {
+		schema = "main"
+	}
+	return "SELECT name FROM pragma_table_list WHERE schema = ? AND type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name",
+		[]interface{}{schema}
+}
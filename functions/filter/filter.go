@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jsavajols/goframework/functions/query"
+)
+
+// maxDepth niveau maximum d'imbrication des parenthèses et des objets JSON
+const maxDepth = 32
+
+// Error erreur de lecture d'un filtre, Pos est la position du caractère dans le texte (à partir de 0)
+// ou -1 pour un filtre JSON
+type Error struct {
+	Pos     int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Pos < 0 {
+		return "Filtre invalide : " + e.Message
+	}
+	return fmt.Sprintf("Filtre invalide à la position %d : %s", e.Pos, e.Message)
+}
+
+// Compile lit le filtre et retourne la clause where (sans le mot clé) et ses paramètres
+// pour le dialecte, utilisables avec Table.GetWhere. Le filtre est au format JSON s'il
+// commence par { et au format texte sinon (voir Parse et ParseJSON).
+// Seules les colonnes de columns sont acceptées, la clause est vide si le filtre est vide
+func Compile(filter string, dialect string, columns ...string) (string, []interface{}, error) {
+	var cond query.Cond
+	var err error
+	if strings.HasPrefix(strings.TrimSpace(filter), "{") {
+		cond, err = ParseJSON([]byte(filter), columns...)
+	} else {
+		cond, err = Parse(filter, columns...)
+	}
+	if err != nil || cond == nil {
+		return "", nil, err
+	}
+	where, args := query.Where(dialect, cond)
+	return where, args, nil
+}
+
+// Sort contrôle un tri au format "colonne [asc|desc], ..." et le retourne
+// avec les noms de colonnes de columns
+func Sort(sort string, columns ...string) (string, error) {
+	if strings.TrimSpace(sort) == "" {
+		return "", nil
+	}
+	parts := strings.Split(sort, ",")
+	for i, part := range parts {
+		words := strings.Fields(part)
+		if len(words) == 0 || len(words) > 2 {
+			return "", &Error{Pos: -1, Message: "tri invalide : " + strings.TrimSpace(part)}
+		}
+		column, ok := allowed(words[0], columns)
+		if !ok {
+			return "", &Error{Pos: -1, Message: "colonne non autorisée : " + words[0]}
+		}
+		if len(words) == 2 {
+			direction := strings.ToLower(words[1])
+			if direction != "asc" && direction != "desc" {
+				return "", &Error{Pos: -1, Message: "sens de tri invalide : " + words[1]}
+			}
+			column += " " + direction
+		}
+		parts[i] = column
+	}
+	return strings.Join(parts, ", "), nil
+}
+
+// allowed retourne la colonne de columns correspondant à name, sans tenir compte de la casse
+func allowed(name string, columns []string) (string, bool) {
+	for _, column := range columns {
+		if strings.EqualFold(column, name) {
+			return column, true
+		}
+	}
+	return "", false
+}
+
+// compare condition de comparaison de column avec value selon l'opérateur op
+func compare(column, op string, value interface{}) (query.Cond, error) {
+	switch op {
+	case "eq", "=":
+		if value == nil {
+			return query.IsNull(column), nil
+		}
+		return query.Eq(column, value), nil
+	case "ne", "!=", "<>":
+		if value == nil {
+			return query.IsNotNull(column), nil
+		}
+		return query.Ne(column, value), nil
+	}
+	if value == nil {
+		return nil, fmt.Errorf("null n'est pas accepté par l'opérateur %s", op)
+	}
+	switch op {
+	case "gt", ">":
+		return query.Gt(column, value), nil
+	case "ge", "gte", ">=":
+		return query.Gte(column, value), nil
+	case "lt", "<":
+		return query.Lt(column, value), nil
+	case "le", "lte", "<=":
+		return query.Lte(column, value), nil
+	case "like", "contains", "startswith", "endswith":
+		pattern, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("l'opérateur %s attend une chaine", op)
+		}
+		switch op {
+		case "contains":
+			pattern = "%" + pattern + "%"
+		case "startswith":
+			pattern = pattern + "%"
+		case "endswith":
+			pattern = "%" + pattern
+		}
+		return query.Like(column, pattern), nil
+	}
+	return nil, fmt.Errorf("opérateur inconnu : %s", op)
+}
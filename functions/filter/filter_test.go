@@ -0,0 +1,105 @@
+package filter_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jsavajols/goframework/functions/filter"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   string
+		args   []interface{}
+	}{
+		{"", "", nil},
+		{"email eq 'x' or age gt 18", "(email = ? OR age > ?)", []interface{}{"x", int64(18)}},
+		{"email eq 'it''s'", "email = ?", []interface{}{"it's"}},
+		{"not (email like 'a%') and age in (1, 2)", "(NOT (email LIKE ?) AND age IN (?, ?))", []interface{}{"a%", int64(1), int64(2)}},
+		{"age between 1 and 5", "age BETWEEN ? AND ?", []interface{}{int64(1), int64(5)}},
+		{"email eq null", "email IS NULL", nil},
+		{"Email contains 'a'", "email LIKE ?", []interface{}{"%a%"}},
+		{"email startswith 'a'", "email LIKE ?", []interface{}{"a%"}},
+		{"email eq 'x; drop table users --'", "email = ?", []interface{}{"x; drop table users --"}},
+	}
+	for _, test := range tests {
+		got, args, err := filter.Compile(test.filter, "mysql", "email", "age")
+		if err != nil {
+			t.Errorf("Compile(%q) : %v", test.filter, err)
+			continue
+		}
+		if got != test.want || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("Compile(%q) = %q %#v, attendu %q %#v", test.filter, got, args, test.want, test.args)
+		}
+	}
+}
+
+func TestCompileRejected(t *testing.T) {
+	tests := []struct {
+		filter string
+		pos    int
+	}{
+		// 1 n'est pas une colonne autorisée
+		{"email eq 'x' or 1 eq 1", 16},
+		{"password eq 'x'", 0},
+		{"email eq 'x'; drop table users", 12},
+		{"email eq 'x' -- commentaire", 13},
+		{"email eq", 8},
+		{"email eq 'x", 9},
+		{"(email eq 'x'", 13},
+	}
+	for _, test := range tests {
+		_, _, err := filter.Compile(test.filter, "mysql", "email", "age")
+		var filterErr *filter.Error
+		if !errors.As(err, &filterErr) {
+			t.Errorf("Compile(%q) : erreur %v, attendu *filter.Error", test.filter, err)
+			continue
+		}
+		if filterErr.Pos != test.pos {
+			t.Errorf("Compile(%q) : position %d, attendu %d (%v)", test.filter, filterErr.Pos, test.pos, err)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		sort  string
+		want  string
+		valid bool
+	}{
+		{"", "", true},
+		{"age asc", "age asc", true},
+		{"email desc, age", "email desc, age", true},
+		{"password", "", false},
+		{"email; drop table users", "", false},
+		{"email sideways", "", false},
+	}
+	for _, test := range tests {
+		got, err := filter.Sort(test.sort, "email", "age")
+		if (err == nil) != test.valid || got != test.want {
+			t.Errorf("Sort(%q) = %q %v, attendu %q valide %v", test.sort, got, err, test.want, test.valid)
+		}
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		json  string
+		valid bool
+	}{
+		{`{"email": "x", "age": {"gt": 18}}`, true},
+		{`{"or": [{"email": "a"}, {"email": null}]}`, true},
+		{`{"not": {"age": {"in": [1, 2]}}}`, true},
+		{`{"password": "x"}`, false},
+		{`{"age": {"bad": 1}}`, false},
+		{`{"email": "x"`, false},
+	}
+	for _, test := range tests {
+		_, err := filter.ParseJSON([]byte(test.json), "email", "age")
+		if (err == nil) != test.valid {
+			t.Errorf("ParseJSON(%s) : %v, attendu valide %v", test.json, err, test.valid)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jsavajols/goframework/functions/query"
+)
+
+// ParseJSON lit un filtre JSON, par exemple :
+//
+//	{"status": "open", "created": {"gt": "2024-01-01"}, "or": [{"priority": {"in": [1, 2]}}, {"closed": null}]}
+//
+// Chaque clé est une colonne comparée à une valeur (égalité, null pour is null)
+// ou à un objet d'opérateurs du filtre texte ; and, or (tableaux) et not (objet) combinent les conditions.
+// Seules les colonnes de columns sont acceptées, la condition est nil si le filtre est vide
+func ParseJSON(data []byte, columns ...string) (query.Cond, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, &Error{Pos: -1, Message: err.Error()}
+	}
+	if len(object) == 0 {
+		return nil, nil
+	}
+	return objectCond(object, columns, 0)
+}
+
+// objectCond conditions d'un objet, combinées avec AND
+func objectCond(object map[string]interface{}, columns []string, depth int) (query.Cond, error) {
+	if depth > maxDepth {
+		return nil, &Error{Pos: -1, Message: "filtre trop imbriqué"}
+	}
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	conds := []query.Cond{}
+	for _, key := range keys {
+		cond, err := keyCond(key, object[key], columns, depth)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+	return query.And(conds...), nil
+}
+
+func keyCond(key string, value interface{}, columns []string, depth int) (query.Cond, error) {
+	switch strings.ToLower(key) {
+	case "and", "or":
+		list, ok := value.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, &Error{Pos: -1, Message: key + " attend un tableau d'objets non vide"}
+		}
+		conds := make([]query.Cond, len(list))
+		for i, item := range list {
+			object, ok := item.(map[string]interface{})
+			if !ok {
+				return nil, &Error{Pos: -1, Message: key + " attend un tableau d'objets"}
+			}
+			cond, err := objectCond(object, columns, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			conds[i] = cond
+		}
+		if strings.ToLower(key) == "or" {
+			return query.Or(conds...), nil
+		}
+		return query.And(conds...), nil
+	case "not":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, &Error{Pos: -1, Message: "not attend un objet"}
+		}
+		cond, err := objectCond(object, columns, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		return query.Not(cond), nil
+	}
+
+	column, ok := allowed(key, columns)
+	if !ok {
+		return nil, &Error{Pos: -1, Message: "colonne non autorisée : " + key}
+	}
+	operators, ok := value.(map[string]interface{})
+	if !ok {
+		v, err := scalar(column, value)
+		if err != nil {
+			return nil, err
+		}
+		cond, _ := compare(column, "eq", v)
+		return cond, nil
+	}
+	ops := make([]string, 0, len(operators))
+	for op := range operators {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	conds := []query.Cond{}
+	for _, op := range ops {
+		cond, err := operatorCond(column, strings.ToLower(op), operators[op])
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+	}
+	if len(conds) == 0 {
+		return nil, &Error{Pos: -1, Message: "aucun opérateur pour " + column}
+	}
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+	return query.And(conds...), nil
+}
+
+func operatorCond(column, op string, value interface{}) (query.Cond, error) {
+	switch op {
+	case "in", "nin", "between":
+		list, ok := value.([]interface{})
+		if !ok || len(list) == 0 || (op == "between" && len(list) != 2) {
+			if op == "between" {
+				return nil, &Error{Pos: -1, Message: column + " : between attend un tableau de deux valeurs"}
+			}
+			return nil, &Error{Pos: -1, Message: column + " : " + op + " attend un tableau non vide"}
+		}
+		values := make([]interface{}, len(list))
+		for i, item := range list {
+			v, err := scalar(column, item)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		switch op {
+		case "nin":
+			return query.NotIn(column, values...), nil
+		case "between":
+			return query.Between(column, values[0], values[1]), nil
+		}
+		return query.In(column, values...), nil
+	case "null":
+		isNull, ok := value.(bool)
+		if !ok {
+			return nil, &Error{Pos: -1, Message: column + " : null attend true ou false"}
+		}
+		if isNull {
+			return query.IsNull(column), nil
+		}
+		return query.IsNotNull(column), nil
+	}
+	v, err := scalar(column, value)
+	if err != nil {
+		return nil, err
+	}
+	cond, err := compare(column, op, v)
+	if err != nil {
+		return nil, &Error{Pos: -1, Message: column + " : " + err.Error()}
+	}
+	return cond, nil
+}
+
+// scalar convertit une valeur JSON en paramètre de requête, les objets et tableaux sont refusés
+func scalar(column string, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return nil, &Error{Pos: -1, Message: fmt.Sprintf("%s : nombre invalide %s", column, v)}
+		}
+		return f, nil
+	}
+	return nil, &Error{Pos: -1, Message: column + " : valeur attendue"}
+}
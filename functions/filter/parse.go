@@ -0,0 +1,325 @@
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jsavajols/goframework/functions/query"
+)
+
+// Parse lit un filtre texte, par exemple :
+//
+//	status eq 'open' and (created gt '2024-01-01' or priority in (1, 2)) and not closed is null
+//
+// Opérateurs : eq, ne, gt, ge, lt, le (ou =, !=, <>, >, >=, <, <=), like, contains,
+// startswith, endswith, in (...), nin (...), between ... and ..., is null, is not null.
+// Valeurs : chaines entre quotes simples (doublées à l'intérieur), nombres, true, false et null.
+// Les conditions se combinent avec and, or, not et des parenthèses.
+// Seules les colonnes de columns sont acceptées, la condition est nil si le filtre est vide
+func Parse(filter string, columns ...string) (query.Cond, error) {
+	tokens, err := tokenize(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	p := &parser{tokens: tokens, columns: columns, end: len([]rune(filter))}
+	cond, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("%s inattendu", p.peek().text)
+	}
+	return cond, nil
+}
+
+type tokenKind int
+
+const (
+	word tokenKind = iota
+	str
+	number
+	symbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// tokenize découpe le filtre en mots, chaines, nombres et symboles
+func tokenize(filter string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(filter)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'':
+			var value strings.Builder
+			start := i
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, &Error{Pos: start, Message: "chaine non terminée"}
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						value.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{str, value.String(), start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{number, string(runes[start:i]), start})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{word, string(runes[start:i]), start})
+		case strings.ContainsRune("(),", r):
+			tokens = append(tokens, token{symbol, string(r), i})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			start := i
+			i++
+			if i < len(runes) && (runes[i] == '=' || (r == '<' && runes[i] == '>')) {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" {
+				return nil, &Error{Pos: start, Message: "opérateur inconnu : !"}
+			}
+			tokens = append(tokens, token{symbol, op, start})
+		default:
+			return nil, &Error{Pos: i, Message: "caractère inattendu : " + string(r)}
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens  []token
+	i       int
+	columns []string
+	end     int
+}
+
+func (p *parser) done() bool { return p.i >= len(p.tokens) }
+
+func (p *parser) peek() token {
+	if p.done() {
+		return token{symbol, "fin du filtre", p.end}
+	}
+	return p.tokens[p.i]
+}
+
+// keyword indique si le prochain élément est le mot clé k et le consomme
+func (p *parser) keyword(k string) bool {
+	if t := p.peek(); !p.done() && t.kind == word && strings.EqualFold(t.text, k) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+// symbol indique si le prochain élément est le symbole s et le consomme
+func (p *parser) symbol(s string) bool {
+	if t := p.peek(); !p.done() && t.kind == symbol && t.text == s {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &Error{Pos: p.peek().pos, Message: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) or(depth int) (query.Cond, error) {
+	conds := []query.Cond{}
+	for {
+		cond, err := p.and(depth)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+		if !p.keyword("or") {
+			break
+		}
+	}
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+	return query.Or(conds...), nil
+}
+
+func (p *parser) and(depth int) (query.Cond, error) {
+	conds := []query.Cond{}
+	for {
+		cond, err := p.unary(depth)
+		if err != nil {
+			return nil, err
+		}
+		conds = append(conds, cond)
+		if !p.keyword("and") {
+			break
+		}
+	}
+	if len(conds) == 1 {
+		return conds[0], nil
+	}
+	return query.And(conds...), nil
+}
+
+func (p *parser) unary(depth int) (query.Cond, error) {
+	if depth > maxDepth {
+		return nil, p.errorf("filtre trop imbriqué")
+	}
+	if p.keyword("not") {
+		cond, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return query.Not(cond), nil
+	}
+	if p.symbol("(") {
+		cond, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if !p.symbol(")") {
+			return nil, p.errorf(") attendue au lieu de %s", p.peek().text)
+		}
+		return cond, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (query.Cond, error) {
+	t := p.peek()
+	if p.done() || t.kind != word {
+		return nil, p.errorf("colonne attendue au lieu de %s", t.text)
+	}
+	column, ok := allowed(t.text, p.columns)
+	if !ok {
+		return nil, p.errorf("colonne non autorisée : %s", t.text)
+	}
+	p.i++
+
+	opToken := p.peek()
+	if p.done() || opToken.kind == str || opToken.kind == number {
+		return nil, p.errorf("opérateur attendu après %s", t.text)
+	}
+	p.i++
+	op := strings.ToLower(opToken.text)
+	switch op {
+	case "is":
+		negate := p.keyword("not")
+		if !p.keyword("null") {
+			return nil, p.errorf("null attendu après is")
+		}
+		if negate {
+			return query.IsNotNull(column), nil
+		}
+		return query.IsNull(column), nil
+	case "in", "nin":
+		if !p.symbol("(") {
+			return nil, p.errorf("( attendue après %s", opToken.text)
+		}
+		values := []interface{}{}
+		for {
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			if p.symbol(")") {
+				break
+			}
+			if !p.symbol(",") {
+				return nil, p.errorf(", ou ) attendue au lieu de %s", p.peek().text)
+			}
+		}
+		if op == "nin" {
+			return query.NotIn(column, values...), nil
+		}
+		return query.In(column, values...), nil
+	case "between":
+		from, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword("and") {
+			return nil, p.errorf("and attendu après between")
+		}
+		to, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return query.Between(column, from, to), nil
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	cond, err := compare(column, op, value)
+	if err != nil {
+		return nil, &Error{Pos: opToken.pos, Message: err.Error()}
+	}
+	return cond, nil
+}
+
+// value lit une chaine, un nombre, true, false ou null
+func (p *parser) value() (interface{}, error) {
+	t := p.peek()
+	if p.done() {
+		return nil, p.errorf("valeur attendue")
+	}
+	switch t.kind {
+	case str:
+		p.i++
+		return t.text, nil
+	case number:
+		p.i++
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, &Error{Pos: t.pos, Message: "nombre invalide : " + t.text}
+		}
+		return f, nil
+	case word:
+		switch strings.ToLower(t.text) {
+		case "true":
+			p.i++
+			return true, nil
+		case "false":
+			p.i++
+			return false, nil
+		case "null":
+			p.i++
+			return nil, nil
+		}
+	}
+	return nil, p.errorf("valeur attendue au lieu de %s", t.text)
+}
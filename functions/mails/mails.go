@@ -61,27 +61,8 @@ func SendMail(vars map[string]string) error {
 
 // ChkMail verifie la cohérence d'une adresse mail
 func ChkMail(pMail string, pChkValid string) (float32, string) {
-	var errorCode float32 = 0
+	errorCode := ChkMailSyntax(pMail)
 	message := ""
-	if errorCode == 0 && !strings.Contains(pMail, ".") {
-		errorCode = -1
-	}
-	if errorCode == 0 && !strings.Contains(pMail, "@") {
-		errorCode = -2
-	}
-	if errorCode == 0 && strings.TrimSpace(pMail) == "" {
-		errorCode = -98
-	}
-	if errorCode == 0 && strings.LastIndex(pMail, "@") > strings.LastIndex(pMail, ".") {
-		errorCode = -3
-	}
-	emailRegex := regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
-	if errorCode == 0 && !emailRegex.MatchString(pMail) {
-		errorCode = -4
-	}
-	if len(pMail) < 3 && len(pMail) > 254 {
-		errorCode = -5
-	}
 	if errorCode == 0 {
 		parts := strings.Split(pMail, "@")
 		mx, err := net.LookupMX(parts[1])
@@ -107,6 +88,34 @@ func ChkMail(pMail string, pChkValid string) (float32, string) {
 	return errorCode, message
 }
 
+// emailRegex syntaxe d'une adresse mail, compilée une seule fois
+var emailRegex = regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}$`)
+
+// ChkMailSyntax verifie la forme d'une adresse mail, sans interroger le serveur
+// Retourne 0 si elle est valide, sinon le code d'erreur de ChkMail
+func ChkMailSyntax(pMail string) float32 {
+	var errorCode float32 = 0
+	if errorCode == 0 && !strings.Contains(pMail, ".") {
+		errorCode = -1
+	}
+	if errorCode == 0 && !strings.Contains(pMail, "@") {
+		errorCode = -2
+	}
+	if errorCode == 0 && strings.TrimSpace(pMail) == "" {
+		errorCode = -98
+	}
+	if errorCode == 0 && strings.LastIndex(pMail, "@") > strings.LastIndex(pMail, ".") {
+		errorCode = -3
+	}
+	if errorCode == 0 && !emailRegex.MatchString(pMail) {
+		errorCode = -4
+	}
+	if errorCode == 0 && (len(pMail) < 3 || len(pMail) > 254) {
+		errorCode = -5
+	}
+	return errorCode
+}
+
 type SmtpError struct {
 	Err error
 }
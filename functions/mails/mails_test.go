@@ -0,0 +1,29 @@
+package mails_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jsavajols/goframework/functions/mails"
+)
+
+func TestChkMailSyntax(t *testing.T) {
+	tests := []struct {
+		mail string
+		want float32
+	}{
+		{"jo@example.com", 0},
+		{"jo.doe+news@mail.example.travel", 0},
+		{"jo@example", -1},
+		{"jo.example.com", -2},
+		{"jo.doe@example", -3},
+		{"jo doe@example.com", -4},
+		{"jo@example.c", -4},
+		{strings.Repeat("a", 250) + "@example.com", -5},
+	}
+	for _, test := range tests {
+		if got := mails.ChkMailSyntax(test.mail); got != test.want {
+			t.Errorf("ChkMailSyntax(%q) = %v, attendu %v", test.mail, got, test.want)
+		}
+	}
+}
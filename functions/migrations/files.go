@@ -0,0 +1,162 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fileName nom des fichiers : 0001_create_users.up.sql, 0001_create_users.down.sql
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Marqueurs des fichiers de migration
+const (
+	// noTransaction la migration est exécutée hors transaction
+	noTransaction = "-- migrate: notransaction"
+	// statementBegin et statementEnd encadrent une requête qui contient des points-virgules
+	// (trigger, procédure...)
+	statementBegin = "-- migrate: statementbegin"
+	statementEnd   = "-- migrate: statementend"
+)
+
+// Load lit les migrations SQL du répertoire dir
+// Une migration peut ne pas avoir de fichier down, elle ne peut alors pas être annulée
+func Load(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	versions := []int64{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Version invalide %s : %w", entry.Name(), err)
+		}
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+			versions = append(versions, version)
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("Version %d en double : %s et %s", version, migration.Name, match[2])
+		}
+		script := string(content)
+		if match[3] == "up" {
+			migration.Up = script
+		} else {
+			migration.Down = script
+		}
+		if strings.Contains(script, noTransaction) {
+			migration.NoTransaction = true
+		}
+	}
+	migrations := make([]Migration, 0, len(versions))
+	for _, version := range versions {
+		if byVersion[version].Up == "" {
+			return nil, fmt.Errorf("Fichier up manquant pour la version %d", version)
+		}
+		migrations = append(migrations, *byVersion[version])
+	}
+	return migrations, nil
+}
+
+// Split découpe un script en requêtes séparées par des points-virgules
+// Les points-virgules des chaines, des identifiants, des commentaires
+// et des blocs $$ de postgres sont ignorés, ainsi que ceux entre les lignes
+// "-- migrate: statementbegin" et "-- migrate: statementend"
+func Split(script string) []string {
+	statements := []string{}
+	var current strings.Builder
+	block := false
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" && !onlyComments(statement) {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(script[i+1:], c)
+			if end == -1 {
+				end = len(script) - i - 2
+			}
+			current.WriteString(script[i : i+end+2])
+			i += end + 1
+			continue
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			end := strings.IndexByte(script[i:], '\n')
+			if end == -1 {
+				end = len(script) - i
+			}
+			comment := strings.TrimSpace(script[i : i+end])
+			switch strings.ToLower(comment) {
+			case statementBegin:
+				flush()
+				block = true
+			case statementEnd:
+				flush()
+				block = false
+			default:
+				current.WriteString(comment)
+			}
+			i += end - 1
+			continue
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end == -1 {
+				end = len(script) - i - 4
+			}
+			current.WriteString(script[i : i+end+4])
+			i += end + 3
+			continue
+		case c == '$':
+			if tag := dollarTag(script[i:]); tag != "" {
+				end := strings.Index(script[i+len(tag):], tag)
+				if end == -1 {
+					end = len(script) - i - 2*len(tag)
+				}
+				current.WriteString(script[i : i+len(tag)+end+len(tag)])
+				i += len(tag) + end + len(tag) - 1
+				continue
+			}
+		case c == ';' && !block:
+			flush()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	flush()
+	return statements
+}
+
+var dollarTagPattern = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+// dollarTag retourne le délimiteur $tag$ qui commence s, vide s'il n'y en a pas
+func dollarTag(s string) string {
+	return dollarTagPattern.FindString(s)
+}
+
+// onlyComments indique si la requête ne contient que des commentaires
+func onlyComments(statement string) bool {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package migrations_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jsavajols/goframework/functions/migrations"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			"requêtes",
+			"create table a (id int);\ncreate table b (id int);\n",
+			[]string{"create table a (id int)", "create table b (id int)"},
+		},
+		{
+			"sans point-virgule final",
+			"insert into a values (1)",
+			[]string{"insert into a values (1)"},
+		},
+		{
+			"chaines et identifiants",
+			"insert into a values ('x;y', \"c;d\", `e;f`); select 1",
+			[]string{"insert into a values ('x;y', \"c;d\", `e;f`)", "select 1"},
+		},
+		{
+			"chaine avec apostrophe doublée",
+			"insert into a values ('it''s; ok'); select 1",
+			[]string{"insert into a values ('it''s; ok')", "select 1"},
+		},
+		{
+			"commentaires",
+			"-- début; du script\nselect 1; /* bloc; commenté */ select 2;\n-- fin;",
+			[]string{"-- début; du script\nselect 1", "/* bloc; commenté */ select 2"},
+		},
+		{
+			"blocs $$ de postgres",
+			"create function f() returns int as $$ begin return 1; end; $$ language plpgsql; select 1",
+			[]string{"create function f() returns int as $$ begin return 1; end; $$ language plpgsql", "select 1"},
+		},
+		{
+			"blocs $tag$ de postgres",
+			"do $body$ begin perform 1; end $body$; select 1",
+			[]string{"do $body$ begin perform 1; end $body$", "select 1"},
+		},
+		{
+			"statementbegin et statementend",
+			"create table a (id int);\n-- migrate: StatementBegin\ncreate trigger t before insert on a begin select 1; end;\n-- migrate: StatementEnd\nselect 1;",
+			[]string{"create table a (id int)", "create trigger t before insert on a begin select 1; end;", "select 1"},
+		},
+		{
+			"script vide",
+			"  \n-- seulement un commentaire\n;",
+			[]string{},
+		},
+	}
+	for _, test := range tests {
+		if got := migrations.Split(test.script); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s : Split =\n%q\nattendu\n%q", test.name, got, test.want)
+		}
+	}
+}
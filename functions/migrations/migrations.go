@@ -0,0 +1,298 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/jsavajols/goframework/functions/dialects"
+	logs "github.com/jsavajols/goframework/functions/logs"
+	sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+)
+
+// DefaultTable table des versions appliquées
+const DefaultTable = "schema_migrations"
+
+// Executor connexion ou transaction passée aux migrations écrites en Go
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// Func migration écrite en Go
+type Func func(ctx context.Context, db Executor) error
+
+// Migration version du schéma, écrite en SQL (Up, Down) ou en Go (UpFunc, DownFunc)
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	UpFunc   Func
+	DownFunc Func
+	// NoTransaction exécute la migration hors transaction, par exemple pour
+	// create index concurrently (ligne "-- migrate: notransaction" dans le fichier)
+	NoTransaction bool
+}
+
+// Status état d'une migration
+type Status struct {
+	Version   int64  `json:"version"`
+	Name      string `json:"name"`
+	Applied   bool   `json:"applied"`
+	AppliedAt string `json:"appliedAt"`
+}
+
+var registered []Migration
+
+// Register ajoute une migration écrite en Go, à appeler dans un init()
+func Register(version int64, name string, up, down Func) {
+	registered = append(registered, Migration{Version: version, Name: name, UpFunc: up, DownFunc: down})
+}
+
+// Migrator applique les migrations du répertoire Dir et celles enregistrées avec Register
+type Migrator struct {
+	Db      *sql.DB
+	Dialect string
+	// Dir répertoire des fichiers 0001_nom.up.sql et 0001_nom.down.sql
+	Dir string
+	// Table table des versions appliquées, DefaultTable si vide
+	Table string
+}
+
+// New retourne un Migrator pour la connexion db
+func New(db *sql.DB, dialect string, dir string) *Migrator {
+	return &Migrator{Db: db, Dialect: dialect, Dir: dir}
+}
+
+// Migrations retourne les migrations connues triées par version
+func (m *Migrator) Migrations() ([]Migration, error) {
+	migrations := []Migration{}
+	if m.Dir != "" {
+		files, err := Load(m.Dir)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, files...)
+	}
+	migrations = append(migrations, registered...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("Version %d en double : %s et %s", migrations[i].Version, migrations[i-1].Name, migrations[i].Name)
+		}
+	}
+	return migrations, nil
+}
+
+// Status retourne l'état de chaque migration, y compris les versions appliquées
+// dont la migration n'existe plus
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := make([]Status, 0, len(migrations))
+	known := map[int64]bool{}
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		appliedAt, ok := applied[migration.Version]
+		status = append(status, Status{Version: migration.Version, Name: migration.Name, Applied: ok, AppliedAt: appliedAt})
+	}
+	for version, appliedAt := range applied {
+		if !known[version] {
+			status = append(status, Status{Version: version, Name: "(inconnue)", Applied: true, AppliedAt: appliedAt})
+		}
+	}
+	sort.Slice(status, func(i, j int) bool { return status[i].Version < status[j].Version })
+	return status, nil
+}
+
+// Up applique les migrations en attente, au plus steps si steps > 0
+// et retourne le nombre de migrations appliquées
+func (m *Migrator) Up(ctx context.Context, steps int) (int, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return 0, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if steps > 0 && count == steps {
+			break
+		}
+		if err := m.run(ctx, migration, true); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Down annule les steps dernières migrations appliquées (une seule si steps <= 0)
+// et retourne le nombre de migrations annulées
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		steps = 1
+	}
+	migrations, err := m.Migrations()
+	if err != nil {
+		return 0, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+		if _, ok := applied[migrations[i].Version]; !ok {
+			continue
+		}
+		if err := m.run(ctx, migrations[i], false); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Redo annule puis réapplique la dernière migration appliquée
+func (m *Migrator) Redo(ctx context.Context) error {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[migrations[i].Version]; ok {
+			if err := m.run(ctx, migrations[i], false); err != nil {
+				return err
+			}
+			return m.run(ctx, migrations[i], true)
+		}
+	}
+	return fmt.Errorf("Aucune migration appliquée")
+}
+
+// run applique (up) ou annule une migration et met à jour la table des versions,
+// dans une transaction si le dialecte le permet
+func (m *Migrator) run(ctx context.Context, migration Migration, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+	logs.Logs("Migration", migration.Version, migration.Name, direction)
+
+	var db Executor = m.Db
+	var tx *sql.Tx
+	if dialects.Must(m.Dialect).TransactionalDDL() && !migration.NoTransaction {
+		var err error
+		tx, err = m.Db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		db = tx
+	}
+	err := m.apply(ctx, db, migration, up)
+	if err == nil && up {
+		_, err = db.ExecContext(ctx, m.rebind("INSERT INTO "+m.table()+" (version, name, applied_at) VALUES (?, ?, ?)"),
+			migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339))
+	} else if err == nil {
+		_, err = db.ExecContext(ctx, m.rebind("DELETE FROM "+m.table()+" WHERE version = ?"), migration.Version)
+	}
+	if tx != nil {
+		if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("Migration %d %s (%s) : %w", migration.Version, migration.Name, direction, err)
+		log.Error(err.Error())
+	}
+	return err
+}
+
+// apply exécute la fonction ou les requêtes de la migration
+func (m *Migrator) apply(ctx context.Context, db Executor, migration Migration, up bool) error {
+	fn, script := migration.DownFunc, migration.Down
+	if up {
+		fn, script = migration.UpFunc, migration.Up
+	}
+	if fn != nil {
+		return fn(ctx, db)
+	}
+	if strings.TrimSpace(script) == "" && !up {
+		return fmt.Errorf("Pas de migration down, la version ne peut pas être annulée")
+	}
+	for _, statement := range Split(script) {
+		logs.Logs(statement)
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applied retourne les versions appliquées et leur date, la table des versions est créée si besoin
+func (m *Migrator) applied(ctx context.Context) (map[int64]string, error) {
+	if err := m.createTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := m.Db.QueryContext(ctx, "SELECT version, applied_at FROM "+m.table())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int64]string{}
+	for rows.Next() {
+		var version int64
+		var appliedAt sql.NullString
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt.String
+	}
+	return applied, rows.Err()
+}
+
+// createTable crée la table des versions si elle n'existe pas
+// La date est stockée au format RFC 3339 pour avoir le même type sur tous les dialectes
+func (m *Migrator) createTable(ctx context.Context) error {
+	rows, err := m.Db.QueryContext(ctx, "SELECT version FROM "+m.table()+" WHERE 1 = 0")
+	if err == nil {
+		return rows.Close()
+	}
+	_, err = m.Db.ExecContext(ctx, "CREATE TABLE "+m.table()+" (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, applied_at VARCHAR(32) NOT NULL)")
+	return err
+}
+
+func (m *Migrator) table() string {
+	if m.Table == "" {
+		return DefaultTable
+	}
+	return m.Table
+}
+
+func (m *Migrator) rebind(query string) string {
+	return sqlFunctions.Rebind(query, m.Dialect)
+}
@@ -0,0 +1,409 @@
+package query
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/jsavajols/goframework/functions/dialects"
+	sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+)
+
+// identifier nom de colonne ou de table simple, éventuellement préfixé : table.colonne
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Cond condition d'une clause where ou having
+type Cond interface {
+	render(w *writer)
+}
+
+// writer construit le texte SQL et la liste des paramètres d'une requête
+type writer struct {
+	sb      strings.Builder
+	args    []interface{}
+	dialect string
+	// quote protège les noms de colonnes des conditions
+	quote bool
+}
+
+func (w *writer) write(s ...string) {
+	for _, v := range s {
+		w.sb.WriteString(v)
+	}
+}
+
+// column nom de colonne d'une condition, protégé si quote est vrai
+func (w *writer) column(name string) string {
+	if !w.quote {
+		return strings.TrimSpace(name)
+	}
+	return Quote(name, w.dialect)
+}
+
+// param ajoute un paramètre, les ? sont convertis pour le dialecte par Rebind
+func (w *writer) param(v interface{}) {
+	w.sb.WriteString("?")
+	w.args = append(w.args, v)
+}
+
+// Quote protège un nom de colonne ou de table selon le dialecte,
+// par exemple `col` pour mysql et "col" pour postgres et sqlite
+// Les expressions (fonctions, alias, *) sont retournées telles quelles :
+// un nom venant de l'extérieur doit être contrôlé avec IsIdentifier
+func Quote(name, dialect string) string {
+	name = strings.TrimSpace(name)
+	if !identifier.MatchString(name) {
+		return name
+	}
+	d := dialects.Must(dialect)
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = d.QuoteIdent(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// IsIdentifier indique si name est un nom de colonne ou de table simple (colonne ou table.colonne),
+// à vérifier avant de passer un nom venant de l'extérieur (paramètre de tri...) au Builder
+func IsIdentifier(name string) bool {
+	return identifier.MatchString(strings.TrimSpace(name))
+}
+
+type compare struct {
+	column string
+	op     string
+	value  interface{}
+}
+
+func (c compare) render(w *writer) {
+	w.write(w.column(c.column), " ", c.op, " ")
+	w.param(c.value)
+}
+
+// Eq colonne = valeur
+func Eq(column string, value interface{}) Cond { return compare{column, "=", value} }
+
+// Ne colonne <> valeur
+func Ne(column string, value interface{}) Cond { return compare{column, "<>", value} }
+
+// Gt colonne > valeur
+func Gt(column string, value interface{}) Cond { return compare{column, ">", value} }
+
+// Gte colonne >= valeur
+func Gte(column string, value interface{}) Cond { return compare{column, ">=", value} }
+
+// Lt colonne < valeur
+func Lt(column string, value interface{}) Cond { return compare{column, "<", value} }
+
+// Lte colonne <= valeur
+func Lte(column string, value interface{}) Cond { return compare{column, "<=", value} }
+
+// Like colonne LIKE motif
+func Like(column string, pattern string) Cond { return compare{column, "LIKE", pattern} }
+
+type in struct {
+	column string
+	not    bool
+	values []interface{}
+}
+
+func (c in) render(w *writer) {
+	// Une liste vide ne correspond à aucune ligne (ou à toutes pour NOT IN)
+	if len(c.values) == 0 {
+		if c.not {
+			w.write("1 = 1")
+		} else {
+			w.write("1 = 0")
+		}
+		return
+	}
+	w.write(w.column(c.column))
+	if c.not {
+		w.write(" NOT")
+	}
+	w.write(" IN (")
+	for i, v := range c.values {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.param(v)
+	}
+	w.write(")")
+}
+
+// In colonne IN (valeurs...)
+func In(column string, values ...interface{}) Cond { return in{column, false, values} }
+
+// NotIn colonne NOT IN (valeurs...)
+func NotIn(column string, values ...interface{}) Cond { return in{column, true, values} }
+
+type isNull struct {
+	column string
+	not    bool
+}
+
+func (c isNull) render(w *writer) {
+	w.write(w.column(c.column), " IS ")
+	if c.not {
+		w.write("NOT ")
+	}
+	w.write("NULL")
+}
+
+// IsNull colonne IS NULL
+func IsNull(column string) Cond { return isNull{column, false} }
+
+// IsNotNull colonne IS NOT NULL
+func IsNotNull(column string) Cond { return isNull{column, true} }
+
+type between struct {
+	column   string
+	from, to interface{}
+}
+
+func (c between) render(w *writer) {
+	w.write(w.column(c.column), " BETWEEN ")
+	w.param(c.from)
+	w.write(" AND ")
+	w.param(c.to)
+}
+
+// Between colonne BETWEEN from AND to
+func Between(column string, from, to interface{}) Cond { return between{column, from, to} }
+
+type group struct {
+	op    string
+	conds []Cond
+}
+
+func (g group) render(w *writer) {
+	if len(g.conds) == 0 {
+		// Groupe vide : toujours vrai pour AND, toujours faux pour OR
+		if g.op == "OR" {
+			w.write("1 = 0")
+		} else {
+			w.write("1 = 1")
+		}
+		return
+	}
+	if len(g.conds) == 1 {
+		g.conds[0].render(w)
+		return
+	}
+	w.write("(")
+	for i, c := range g.conds {
+		if i > 0 {
+			w.write(" ", g.op, " ")
+		}
+		c.render(w)
+	}
+	w.write(")")
+}
+
+// And regroupe les conditions avec AND
+func And(conds ...Cond) Cond { return group{"AND", conds} }
+
+// Or regroupe les conditions avec OR
+func Or(conds ...Cond) Cond { return group{"OR", conds} }
+
+type not struct {
+	cond Cond
+}
+
+func (n not) render(w *writer) {
+	w.write("NOT (")
+	n.cond.render(w)
+	w.write(")")
+}
+
+// Not négation de la condition
+func Not(cond Cond) Cond { return not{cond} }
+
+type expr struct {
+	sql  string
+	args []interface{}
+}
+
+func (e expr) render(w *writer) {
+	w.write("(", e.sql, ")")
+	w.args = append(w.args, e.args...)
+}
+
+// Expr condition SQL libre dont les valeurs sont référencées par des ?
+// Le texte n'est pas contrôlé : il ne doit pas provenir de l'extérieur
+func Expr(sql string, args ...interface{}) Cond { return expr{sql, args} }
+
+// Where construit une clause where (sans le mot clé) et ses paramètres pour le dialecte
+// Les valeurs sont référencées par des ?, utilisable avec Table.GetWhere, UpdateWhere et DeleteWhere
+// Les noms de colonnes ne sont pas protégés : GetWhere adapte les quotes de la requête au dialecte
+func Where(dialect string, conds ...Cond) (string, []interface{}) {
+	w := &writer{dialect: dialect}
+	And(conds...).render(w)
+	return w.sb.String(), w.args
+}
+
+type join struct {
+	kind  string
+	table string
+	on    Cond
+}
+
+// Builder requête select composable, construite avec Select
+// Les valeurs des conditions sont passées en paramètres, mais les noms de tables, de colonnes,
+// de jointures et de tris qui ne sont pas de simples identifiants sont écrits tels quels :
+// ils ne doivent pas provenir de l'extérieur sans être contrôlés avec IsIdentifier
+type Builder struct {
+	columns []string
+	from    string
+	joins   []join
+	where   []Cond
+	groupBy []string
+	having  []Cond
+	orderBy []string
+	limit   int
+	offset  int
+}
+
+// Select démarre une requête sur les colonnes indiquées, toutes (*) si aucune
+func Select(columns ...string) *Builder {
+	return &Builder{columns: columns}
+}
+
+// From table de la requête
+func (b *Builder) From(table string) *Builder {
+	b.from = table
+	return b
+}
+
+// Join jointure interne sur la condition on, par exemple Expr("u.id = o.user_id")
+func (b *Builder) Join(table string, on Cond) *Builder {
+	b.joins = append(b.joins, join{"INNER JOIN", table, on})
+	return b
+}
+
+// LeftJoin jointure externe gauche sur la condition on
+func (b *Builder) LeftJoin(table string, on Cond) *Builder {
+	b.joins = append(b.joins, join{"LEFT JOIN", table, on})
+	return b
+}
+
+// RightJoin jointure externe droite sur la condition on
+func (b *Builder) RightJoin(table string, on Cond) *Builder {
+	b.joins = append(b.joins, join{"RIGHT JOIN", table, on})
+	return b
+}
+
+// Where ajoute des conditions, combinées avec AND aux précédentes
+func (b *Builder) Where(conds ...Cond) *Builder {
+	b.where = append(b.where, conds...)
+	return b
+}
+
+// GroupBy colonnes de regroupement
+func (b *Builder) GroupBy(columns ...string) *Builder {
+	b.groupBy = append(b.groupBy, columns...)
+	return b
+}
+
+// Having conditions sur les regroupements, combinées avec AND
+func (b *Builder) Having(conds ...Cond) *Builder {
+	b.having = append(b.having, conds...)
+	return b
+}
+
+// OrderBy colonnes de tri, suivies éventuellement de asc ou desc : OrderBy("name", "id desc")
+// Une expression est écrite telle quelle, voir IsIdentifier
+func (b *Builder) OrderBy(columns ...string) *Builder {
+	b.orderBy = append(b.orderBy, columns...)
+	return b
+}
+
+// Limit nombre maximum de lignes retournées, sans limite si 0
+func (b *Builder) Limit(limit int) *Builder {
+	b.limit = limit
+	return b
+}
+
+// Offset nombre de lignes ignorées
+func (b *Builder) Offset(offset int) *Builder {
+	b.offset = offset
+	return b
+}
+
+// BuildCount retourne la requête qui compte les lignes de la requête, sans tri ni pagination
+// Le comptage porte directement sur la table, ou sur une sous-requête en cas de regroupement ou de distinct
+func (b *Builder) BuildCount(dialect string) (string, []interface{}) {
+	count := *b
+	count.orderBy = nil
+	count.limit = 0
+	count.offset = 0
+	distinct := len(b.columns) > 0 && strings.HasPrefix(strings.ToLower(strings.TrimSpace(b.columns[0])), "distinct")
+	if len(b.groupBy) == 0 && len(b.having) == 0 && !distinct {
+		count.columns = []string{"COUNT(*)"}
+		return count.Build(dialect)
+	}
+	query, args := count.Build(dialect)
+	return "SELECT COUNT(*) FROM (" + query + ") count_query", args
+}
+
+// Limits retourne la limite et le décalage de la requête
+func (b *Builder) Limits() (int, int) {
+	return b.limit, b.offset
+}
+
+// Build retourne le texte SQL de la requête pour le dialecte et ses paramètres
+func (b *Builder) Build(dialect string) (string, []interface{}) {
+	w := &writer{dialect: dialect, quote: true}
+	w.write("SELECT ")
+	if len(b.columns) == 0 {
+		w.write("*")
+	}
+	for i, column := range b.columns {
+		if i > 0 {
+			w.write(", ")
+		}
+		w.write(Quote(column, dialect))
+	}
+	w.write(" FROM ", Quote(b.from, dialect))
+	for _, j := range b.joins {
+		w.write(" ", j.kind, " ", Quote(j.table, dialect), " ON ")
+		j.on.render(w)
+	}
+	if len(b.where) > 0 {
+		w.write(" WHERE ")
+		And(b.where...).render(w)
+	}
+	if len(b.groupBy) > 0 {
+		w.write(" GROUP BY ")
+		for i, column := range b.groupBy {
+			if i > 0 {
+				w.write(", ")
+			}
+			w.write(Quote(column, dialect))
+		}
+	}
+	if len(b.having) > 0 {
+		w.write(" HAVING ")
+		And(b.having...).render(w)
+	}
+	if len(b.orderBy) > 0 {
+		w.write(" ORDER BY ")
+		for i, column := range b.orderBy {
+			if i > 0 {
+				w.write(", ")
+			}
+			w.write(orderColumn(column, dialect))
+		}
+	}
+	w.write(dialects.Must(dialect).Paginate(b.limit, b.offset, len(b.orderBy) > 0))
+	return sqlFunctions.Rebind(w.sb.String(), dialect), w.args
+}
+
+// orderColumn protège le nom de colonne d'un tri "colonne [asc|desc]",
+// les autres expressions sont retournées telles quelles
+func orderColumn(column, dialect string) string {
+	fields := strings.Fields(column)
+	if len(fields) == 2 && (strings.EqualFold(fields[1], "asc") || strings.EqualFold(fields[1], "desc")) {
+		return Quote(fields[0], dialect) + " " + strings.ToUpper(fields[1])
+	}
+	return Quote(column, dialect)
+}
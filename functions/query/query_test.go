@@ -0,0 +1,147 @@
+package query_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jsavajols/goframework/functions/query"
+)
+
+func TestBuild(t *testing.T) {
+	users := func() *query.Builder {
+		return query.Select("id", "name").From("users").
+			Where(query.Eq("status", "active"), query.In("role", "a", "b"), query.Or(query.IsNull("deleted_at"), query.Gt("age", 18))).
+			OrderBy("name", "id desc").Limit(10).Offset(20)
+	}
+	tests := []struct {
+		builder *query.Builder
+		dialect string
+		want    string
+		args    []interface{}
+	}{
+		{
+			users(), "mysql",
+			"SELECT `id`, `name` FROM `users` WHERE (`status` = ? AND `role` IN (?, ?) AND (`deleted_at` IS NULL OR `age` > ?)) ORDER BY `name`, `id` DESC LIMIT 10 OFFSET 20",
+			[]interface{}{"active", "a", "b", 18},
+		},
+		{
+			users(), "postgres",
+			`SELECT "id", "name" FROM "users" WHERE ("status" = $1 AND "role" IN ($2, $3) AND ("deleted_at" IS NULL OR "age" > $4)) ORDER BY "name", "id" DESC LIMIT 10 OFFSET 20`,
+			[]interface{}{"active", "a", "b", 18},
+		},
+		{
+			users(), "mssql",
+			"SELECT [id], [name] FROM [users] WHERE ([status] = @p1 AND [role] IN (@p2, @p3) AND ([deleted_at] IS NULL OR [age] > @p4)) ORDER BY [name], [id] DESC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY",
+			[]interface{}{"active", "a", "b", 18},
+		},
+		{
+			query.Select().From("u").Join("o", query.Expr("u.id = o.user_id")).Where(query.NotIn("o.status")), "postgres",
+			`SELECT * FROM "u" INNER JOIN "o" ON (u.id = o.user_id) WHERE 1 = 1`,
+			nil,
+		},
+		{
+			query.Select("role", "COUNT(*) AS n").From("users").GroupBy("role").Having(query.Expr("COUNT(*) > ?", 2)), "sqlite3",
+			`SELECT "role", COUNT(*) AS n FROM "users" GROUP BY "role" HAVING (COUNT(*) > ?)`,
+			[]interface{}{2},
+		},
+		{
+			// Les valeurs sont toujours passées en paramètres
+			query.Select("id").From("users").Where(query.Eq("name", "x' or 1=1 --")), "mysql",
+			"SELECT `id` FROM `users` WHERE `name` = ?",
+			[]interface{}{"x' or 1=1 --"},
+		},
+	}
+	for _, test := range tests {
+		got, args := test.builder.Build(test.dialect)
+		if got != test.want {
+			t.Errorf("Build(%q) =\n%q\nattendu\n%q", test.dialect, got, test.want)
+		}
+		if !reflect.DeepEqual(args, test.args) {
+			t.Errorf("Build(%q) paramètres = %v, attendu %v", test.dialect, args, test.args)
+		}
+	}
+}
+
+func TestBuildCount(t *testing.T) {
+	tests := []struct {
+		builder *query.Builder
+		want    string
+	}{
+		{
+			query.Select("id").From("users").Where(query.Eq("status", "active")).OrderBy("id").Limit(10),
+			`SELECT COUNT(*) FROM "users" WHERE "status" = $1`,
+		},
+		{
+			query.Select("role").From("users").GroupBy("role"),
+			`SELECT COUNT(*) FROM (SELECT "role" FROM "users" GROUP BY "role") count_query`,
+		},
+		{
+			query.Select("DISTINCT role").From("users"),
+			`SELECT COUNT(*) FROM (SELECT DISTINCT role FROM "users") count_query`,
+		},
+	}
+	for _, test := range tests {
+		if got, _ := test.builder.BuildCount("postgres"); got != test.want {
+			t.Errorf("BuildCount =\n%q\nattendu\n%q", got, test.want)
+		}
+	}
+}
+
+func TestWhere(t *testing.T) {
+	tests := []struct {
+		conds []query.Cond
+		want  string
+		args  []interface{}
+	}{
+		{[]query.Cond{query.Eq("a", 1), query.Between("b", 2, 3)}, "(a = ? AND b BETWEEN ? AND ?)", []interface{}{1, 2, 3}},
+		{[]query.Cond{query.In("a")}, "1 = 0", nil},
+		{[]query.Cond{query.Or()}, "1 = 0", nil},
+		{[]query.Cond{query.Not(query.Like("name", "a%"))}, "NOT (name LIKE ?)", []interface{}{"a%"}},
+		{nil, "1 = 1", nil},
+	}
+	for _, test := range tests {
+		got, args := query.Where("postgres", test.conds...)
+		if got != test.want || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("Where = %q %v, attendu %q %v", got, args, test.want, test.args)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name, dialect string
+		want          string
+	}{
+		{"id", "mysql", "`id`"},
+		{"u.id", "postgres", `"u"."id"`},
+		{"id", "mssql", "[id]"},
+		{"COUNT(*)", "postgres", "COUNT(*)"},
+		{"*", "mysql", "*"},
+	}
+	for _, test := range tests {
+		if got := query.Quote(test.name, test.dialect); got != test.want {
+			t.Errorf("Quote(%q, %q) = %q, attendu %q", test.name, test.dialect, got, test.want)
+		}
+	}
+}
+
+func TestIsIdentifier(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"id", true},
+		{"users.id", true},
+		{"_col1", true},
+		{"1col", false},
+		{"a.b.c", false},
+		{"id desc", false},
+		{"id; drop table users", false},
+		{"count(*)", false},
+	}
+	for _, test := range tests {
+		if got := query.IsIdentifier(test.name); got != test.want {
+			t.Errorf("IsIdentifier(%q) = %v, attendu %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jsavajols/goframework/functions/database"
+	"github.com/jsavajols/goframework/functions/filter"
+	logs "github.com/jsavajols/goframework/functions/logs"
+	"github.com/jsavajols/goframework/functions/tables"
+)
+
+// Options paramètres des routes d'une table
+type Options struct {
+	// IDField colonne utilisée par les routes /:id, Table.PrimaryKey par défaut, sinon id
+	IDField string
+	// Fields colonnes retournées par la liste et la lecture, * par défaut
+	Fields string
+	// Filterable colonnes utilisables dans les paramètres filter et sort de la liste
+	// (voir le package filter). Si elle est renseignée, le paramètre search n'est plus accepté
+	Filterable []string
+	// RawSearch accepte, sans Filterable, les paramètres search et sort de Table.Get
+	// insérés tels quels dans la requête : à réserver aux appels de confiance
+	RawSearch bool
+	// Writable colonnes acceptées dans le corps de POST et PUT, les routes d'écriture
+	// ne sont pas déclarées si elle est vide
+	Writable []string
+}
+
+// identifier nom de colonne accepté dans le corps des requêtes
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Register déclare sur router les routes CRUD de la table :
+//
+//	GET    path        liste, paramètres filter et sort (colonnes de Options.Filterable),
+//	                   start et limit, count=true ajoute le total à la pagination.
+//	                   search et sort de Table.Get seulement si Options.RawSearch est vrai
+//	GET    path/:id    lecture d'un enregistrement
+//	POST   path        création à partir d'un objet JSON
+//	PUT    path/:id    mise à jour des colonnes de l'objet JSON (PATCH est équivalent)
+//	DELETE path/:id    suppression
+//
+// Les routes d'écriture ne sont pas déclarées si la table est en lecture seule
+// ou si Options.Writable est vide
+func Register(router fiber.Router, path string, table tables.Table, options ...Options) {
+	h := handler{table: table}
+	if len(options) > 0 {
+		h.Options = options[0]
+	}
+	if h.IDField == "" {
+		h.IDField = table.PrimaryKey
+	}
+	if h.IDField == "" {
+		h.IDField = "id"
+	}
+	if h.Fields == "" {
+		h.Fields = "*"
+	}
+	path = strings.TrimRight(path, "/")
+	logs.Logs("Routes REST de la table", table.TableName, "sur", path)
+
+	router.Get(path, h.list)
+	router.Get(path+"/:id", h.get)
+	if table.ReadOnly || len(h.Writable) == 0 {
+		return
+	}
+	router.Post(path, h.create)
+	router.Put(path+"/:id", h.update)
+	router.Patch(path+"/:id", h.update)
+	router.Delete(path+"/:id", h.delete)
+}
+
+// Status retourne le statut HTTP correspondant au StatusCode d'une opération
+func Status(result tables.ReturnFunction) int {
+	if result.StatusCode == 0 {
+		return fiber.StatusInternalServerError
+	}
+	return int(result.StatusCode)
+}
+
+type handler struct {
+	Options
+	table tables.Table
+}
+
+// context contexte des requêtes : les lectures qui suivent une écriture utilisent la connexion principale
+func (h handler) context(c *fiber.Ctx) context.Context {
+	return database.WithReadYourWrites(c.UserContext())
+}
+
+func (h handler) list(c *fiber.Ctx) error {
+	table := h.table
+	if c.QueryBool("count") {
+		table.CountTotal = true
+	}
+	if len(h.Filterable) == 0 {
+		if !h.RawSearch && (c.Query("search") != "" || c.Query("sort") != "" || c.Query("filter") != "") {
+			return badRequest(c, "Get error", errors.New("Filtre et tri non acceptés : aucune colonne filtrable"))
+		}
+		result := table.GetContext(h.context(c), h.Fields, c.Query("search"), c.Query("sort"),
+			c.QueryInt("start"), c.QueryInt("limit"))
+		return c.Status(Status(result)).JSON(result)
+	}
+	if c.Query("search") != "" {
+		return badRequest(c, "Get error", errors.New("Paramètre search non accepté, utiliser filter"))
+	}
+	where, args, err := filter.Compile(c.Query("filter"), h.table.Dialect, h.Filterable...)
+	if err != nil {
+		return badRequest(c, "Get error", err)
+	}
+	sort, err := filter.Sort(c.Query("sort"), h.Filterable...)
+	if err != nil {
+		return badRequest(c, "Get error", err)
+	}
+	result := table.GetWhereContext(h.context(c), h.Fields, where, args, sort, c.QueryInt("start"), c.QueryInt("limit"))
+	return c.Status(Status(result)).JSON(result)
+}
+
+func (h handler) get(c *fiber.Ctx) error {
+	result := h.table.GetWhereContext(h.context(c), h.Fields, h.IDField+" = ?", []interface{}{c.Params("id")}, "", 0, 1)
+	if result.StatusCode != fiber.StatusOK {
+		return c.Status(Status(result)).JSON(result)
+	}
+	rows := reflect.ValueOf(result.Rows)
+	if rows.Kind() != reflect.Slice || rows.Len() == 0 {
+		return notFound(c, "Get error")
+	}
+	return c.JSON(rows.Index(0).Interface())
+}
+
+func (h handler) create(c *fiber.Ctx) error {
+	fields, values, err := h.body(c)
+	if err != nil {
+		return badRequest(c, "Insert error", err)
+	}
+	table := h.table
+	result := table.InsertContext(h.context(c), "("+strings.Join(fields, ", ")+")", values)
+	if result.StatusCode == fiber.StatusOK {
+		result.StatusCode = fiber.StatusCreated
+	}
+	return c.Status(Status(result)).JSON(result)
+}
+
+func (h handler) update(c *fiber.Ctx) error {
+	fields, values, err := h.body(c)
+	if err != nil {
+		return badRequest(c, "Update error", err)
+	}
+	ctx := h.context(c)
+	result := h.table.UpdateWhereContext(ctx, fields, values, h.IDField+" = ?", c.Params("id"))
+	// mysql compte 0 ligne si les valeurs sont inchangées : l'existence de la ligne est vérifiée
+	if result.StatusCode == fiber.StatusOK && result.UpdateRecords == 0 {
+		exists := h.table.GetWhereContext(ctx, h.IDField, h.IDField+" = ?", []interface{}{c.Params("id")}, "", 0, 1)
+		if exists.StatusCode != fiber.StatusOK {
+			return c.Status(Status(exists)).JSON(exists)
+		}
+		if exists.GetRecords == 0 {
+			return notFound(c, "Update error")
+		}
+	}
+	return c.Status(Status(result)).JSON(result)
+}
+
+func (h handler) delete(c *fiber.Ctx) error {
+	result := h.table.DeleteWhereContext(h.context(c), h.IDField+" = ?", c.Params("id"))
+	return c.Status(Status(result)).JSON(result)
+}
+
+// body retourne les colonnes, triées par nom, et les valeurs de l'objet JSON de la requête
+func (h handler) body(c *fiber.Ctx) ([]string, []interface{}, error) {
+	var record map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(c.Body()))
+	decoder.UseNumber()
+	if err := decoder.Decode(&record); err != nil {
+		return nil, nil, err
+	}
+	if len(record) == 0 {
+		return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Aucune colonne dans le corps de la requête")
+	}
+	fields := make([]string, 0, len(record))
+	for field := range record {
+		if !identifier.MatchString(field) {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Nom de colonne invalide : "+field)
+		}
+		if !h.writable(field) {
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Colonne non modifiable : "+field)
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	values := make([]interface{}, len(fields))
+	for i, field := range fields {
+		switch value := record[field].(type) {
+		case nil, string, bool:
+			values[i] = value
+		case json.Number:
+			// Les nombres entiers restent exacts au-delà de 2^53
+			if n, err := value.Int64(); err == nil {
+				values[i] = n
+			} else if f, err := value.Float64(); err == nil {
+				values[i] = f
+			} else {
+				return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Nombre invalide pour la colonne "+field)
+			}
+		default:
+			// Les objets et tableaux ne sont pas des valeurs de colonne
+			return nil, nil, fiber.NewError(fiber.StatusBadRequest, "Valeur simple attendue pour la colonne "+field)
+		}
+	}
+	return fields, values, nil
+}
+
+// writable indique si la colonne fait partie de Options.Writable
+func (h handler) writable(field string) bool {
+	for _, column := range h.Writable {
+		if strings.EqualFold(column, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func notFound(c *fiber.Ctx, message string) error {
+	return c.Status(fiber.StatusNotFound).JSON(tables.ReturnFunction{
+		StatusCode:   fiber.StatusNotFound,
+		Message:      message,
+		ErrorMessage: tables.ErrNotFound.Error(),
+		ErrorCode:    "not_found",
+	})
+}
+
+func badRequest(c *fiber.Ctx, message string, err error) error {
+	return c.Status(fiber.StatusBadRequest).JSON(tables.ReturnFunction{
+		StatusCode:   fiber.StatusBadRequest,
+		Message:      message,
+		ErrorMessage: err.Error(),
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/jsavajols/goframework/functions/dialects"
 	"github.com/jsavajols/goframework/functions/logs"
 )
 
@@ -15,16 +16,17 @@ var SuspiciousPatterns = []*regexp.Regexp{
 	regexp.MustCompile(`;`),   // Statement terminator
 }
 
+// DateDiff expression de différence entre deux expressions de dates pour le dialecte
+// Retourne une chaine vide si le dialecte est vide ou inconnu
 func DateDiff(unit, date1, date2, dialect string) string {
-	if dialect == "mysql" {
-		return "DATEDIFF(" + date1 + "," + date2 + ")"
-	} else if dialect == "postgres" {
-		return "DATE_PART('" + unit + "'," + date1 + "::timestamp - " + date2 + "::timestamp)"
-	} else if dialect == "sqlite3" {
-		return "JULIANDAY(" + date1 + ") - JULIANDAY(" + date2 + ")"
-	} else {
+	if dialect == "" {
 		return ""
 	}
+	d, err := dialects.Get(dialect)
+	if err != nil {
+		return ""
+	}
+	return d.DateDiff(unit, date1, date2)
 }
 
 // CheckForSQLInjection inspects a string for potential SQL injection patterns
@@ -38,3 +40,47 @@ func CheckForSQLInjection(input string) bool {
 	}
 	return false
 }
+
+// Rebind convertit les marqueurs ? d'une requête dans le format attendu par le dialecte
+// ($1, $2... pour postgres). Les ? placés entre quotes ne sont pas modifiés.
+func Rebind(query, dialect string) string {
+	d := dialects.Must(dialect)
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+	var sb strings.Builder
+	n := 0
+	var quote rune
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			sb.WriteString(d.Placeholder(n))
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// Placeholder retourne le marqueur de paramètre numéro n (à partir de 1) pour le dialecte
+func Placeholder(n int, dialect string) string {
+	return dialects.Must(dialect).Placeholder(n)
+}
+
+// Placeholders retourne la liste entre parenthèses des marqueurs de paramètres
+// de from+1 à from+count, par exemple ($1, $2, $3) pour postgres
+func Placeholders(from, count int, dialect string) string {
+	d := dialects.Must(dialect)
+	list := make([]string, count)
+	for i := 0; i < count; i++ {
+		list[i] = d.Placeholder(from + i + 1)
+	}
+	return "(" + strings.Join(list, ", ") + ")"
+}
@@ -0,0 +1,83 @@
+package sql_test
+
+import (
+	"testing"
+
+	sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		query, dialect string
+		want           string
+	}{
+		{"select * from t where a = ? and b = ?", "mysql", "select * from t where a = ? and b = ?"},
+		{"select * from t where a = ? and b = ?", "sqlite3", "select * from t where a = ? and b = ?"},
+		{"select * from t where a = ? and b = ?", "postgres", "select * from t where a = $1 and b = $2"},
+		{"select * from t where a = ? and b = ?", "mssql", "select * from t where a = @p1 and b = @p2"},
+		{"select * from t where a = '?' and b = ?", "postgres", "select * from t where a = '?' and b = $1"},
+		{`select "a?b" from t where c = ?`, "postgres", `select "a?b" from t where c = $1`},
+		{"select * from t where a = 'it''s ?' and b = ?", "postgres", "select * from t where a = 'it''s ?' and b = $1"},
+		{"select * from t", "postgres", "select * from t"},
+	}
+	for _, test := range tests {
+		if got := sqlFunctions.Rebind(test.query, test.dialect); got != test.want {
+			t.Errorf("Rebind(%q, %q) = %q, attendu %q", test.query, test.dialect, got, test.want)
+		}
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		from, count int
+		dialect     string
+		want        string
+	}{
+		{0, 3, "postgres", "($1, $2, $3)"},
+		{2, 2, "postgres", "($3, $4)"},
+		{0, 2, "mysql", "(?, ?)"},
+		{0, 2, "mssql", "(@p1, @p2)"},
+	}
+	for _, test := range tests {
+		if got := sqlFunctions.Placeholders(test.from, test.count, test.dialect); got != test.want {
+			t.Errorf("Placeholders(%d, %d, %q) = %q, attendu %q", test.from, test.count, test.dialect, got, test.want)
+		}
+	}
+}
+
+func TestCheckForSQLInjection(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"select * from t where id = ?", false},
+		{"select * from t where 1=1", true},
+		{"select * from t -- commentaire", true},
+		{"select * from t /* commentaire */", true},
+		{"select * from t; drop table t", true},
+	}
+	for _, test := range tests {
+		if got := sqlFunctions.CheckForSQLInjection(test.input); got != test.want {
+			t.Errorf("CheckForSQLInjection(%q) = %v, attendu %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestDateDiff(t *testing.T) {
+	tests := []struct {
+		dialect string
+		want    string
+	}{
+		{"", ""},
+		{"oracle", ""},
+		{"mysql", "DATEDIFF(end_date,start_date)"},
+		{"postgres", "DATE_PART('day',end_date::timestamp - start_date::timestamp)"},
+		{"sqlite3", "JULIANDAY(end_date) - JULIANDAY(start_date)"},
+		{"mssql", "DATEDIFF(day, start_date, end_date)"},
+	}
+	for _, test := range tests {
+		if got := sqlFunctions.DateDiff("day", "end_date", "start_date", test.dialect); got != test.want {
+			t.Errorf("DateDiff(%q) = %q, attendu %q", test.dialect, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package tables
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	con "github.com/jsavajols/goframework/const"
+	"github.com/jsavajols/goframework/functions/database"
+	sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+
+	logs "github.com/jsavajols/goframework/functions/logs"
+)
+
+// RowError erreur sur une ligne d'une insertion en masse, Row est l'indice dans rows
+type RowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+	// Fields erreurs par colonne des règles du tag validate de Struct
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// RowValidator est implémenté par les Validator qui contrôlent chaque ligne de BulkInsert
+// Une ligne en erreur n'est pas insérée et est signalée dans ReturnFunction.RowErrors
+type RowValidator interface {
+	ValidateRow(row int, values []interface{}) error
+}
+
+// BulkInsert insère rows par lots de requêtes INSERT multi-lignes dans une transaction
+// fields est la liste des colonnes au format de Insert : "(a, b, c)"
+// BeforeInsert et AfterInsert sont appelés pour chaque lot, ValidateRow pour chaque ligne
+// si le Validator implémente RowValidator. BeforeInsertRecord et AfterInsertRecord sont appelés
+// pour chaque ligne, sans LastInsertId. En cas d'erreur SQL, aucune ligne n'est insérée.
+func (t *Table) BulkInsert(fields string, rows [][]interface{}) ReturnFunction {
+	return t.BulkInsertContext(context.Background(), fields, rows)
+}
+
+// BulkInsertContext version de BulkInsert interrompue à l'annulation de ctx
+func (t *Table) BulkInsertContext(ctx context.Context, fields string, rows [][]interface{}) ReturnFunction {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+	logs.Logs("Début de l'insertion en masse dans", t.TableName)
+	if t.ReadOnly {
+		return failure(ctx, "Insert error", ErrReadOnly)
+	}
+	if err := t.bindTx(); err != nil {
+		return failure(ctx, "Insert error", err)
+	}
+
+	// Contrôle des lignes avant insertion
+	columns := splitFields(fields)
+	nbFields := len(columns)
+	var schema *database.TableSchema
+	if t.CheckSchema {
+		tableSchema, err := t.Schema(ctx)
+		if err != nil {
+			return failure(ctx, "Insert error", err)
+		}
+		schema = &tableSchema
+	}
+
+	// Utilise la transaction de la table ou en démarre une
+	table := *t
+	ownTx := table.Tx == nil
+	if ownTx {
+		tx, err := t.BeginContext(ctx)
+		if err != nil {
+			return failure(ctx, "Insert error", err)
+		}
+		table.Tx = tx
+		if err := table.bindTx(); err != nil {
+			tx.Rollback()
+			return failure(ctx, "Insert error", err)
+		}
+	}
+	t.Dialect = table.Tx.Dialect
+	table.Dialect = t.Dialect
+
+	// Contrôle de chaque ligne, après BeforeInsertRecord qui peut la modifier
+	// Les colonnes de toutes les lignes doivent rester celles de la première ligne valide
+	rowValidator, _ := t.Validator.(RowValidator)
+	_, rowHook := t.Validator.(BeforeInsertHook)
+	var rowErrors []RowError
+	valid := make([]*Record, 0, len(rows))
+	for i, row := range rows {
+		if len(row) != nbFields {
+			rowErrors = append(rowErrors, RowError{Row: i, Error: fmt.Sprintf("%d valeurs pour %d champs", len(row), nbFields)})
+			continue
+		}
+		record := &Record{Fields: append([]string{}, columns...), Values: append([]interface{}{}, row...)}
+		if rowHook {
+			if err := table.beforeInsert(ctx, record); err != nil {
+				rowErrors = append(rowErrors, RowError{Row: i, Error: err.Error(), Fields: fieldErrors(err)})
+				continue
+			}
+			if len(valid) > 0 && !sameFields(record.Fields, valid[0].Fields) {
+				rowErrors = append(rowErrors, RowError{Row: i, Error: "Colonnes modifiées par BeforeInsertRecord : " + strings.Join(record.Fields, ", ")})
+				continue
+			}
+		}
+		if err := t.ValidateValues(record.Fields, record.Values, true); err != nil {
+			rowErrors = append(rowErrors, RowError{Row: i, Error: err.Error(), Fields: fieldErrors(err)})
+			continue
+		}
+		if schema != nil {
+			if err := schema.ValidateValues(record.Fields, record.Values, true); err != nil {
+				rowErrors = append(rowErrors, RowError{Row: i, Error: err.Error()})
+				continue
+			}
+		}
+		if rowValidator != nil {
+			if err := rowValidator.ValidateRow(i, record.Values); err != nil {
+				rowErrors = append(rowErrors, RowError{Row: i, Error: err.Error()})
+				continue
+			}
+		}
+		valid = append(valid, record)
+	}
+	if len(valid) > 0 {
+		columns = valid[0].Fields
+		nbFields = len(columns)
+		fields = "(" + strings.Join(columns, ", ") + ")"
+	}
+
+	var inserted int64
+	var err error
+	batchSize := con.BULK_BATCH_SIZE
+	if len(valid) > 0 && (nbFields == 0 || t.dialect().MaxPlaceholders() < nbFields) {
+		// Une ligne ne tient pas dans une requête
+		err = &Error{Kind: ErrValidation, Err: fmt.Errorf("%d colonnes par ligne, le dialecte %s accepte au plus %d paramètres par requête",
+			nbFields, t.dialect().Name(), t.dialect().MaxPlaceholders())}
+	} else if limit := t.dialect().MaxPlaceholders() / max(nbFields, 1); limit < batchSize {
+		batchSize = limit
+	}
+	for start := 0; start < len(valid) && err == nil; start += batchSize {
+		end := start + batchSize
+		if end > len(valid) {
+			end = len(valid)
+		}
+		var n int64
+		n, err = table.insertBatch(ctx, fields, nbFields, valid[start:end])
+		inserted += n
+	}
+
+	if err == nil && ownTx {
+		err = table.Tx.Commit()
+	} else if err != nil {
+		if ownTx {
+			table.Tx.Rollback()
+		} else {
+			table.abort(err)
+		}
+	}
+	if err != nil {
+		log.Error(err.Error())
+		returnFunction := failure(ctx, "Insert error", err)
+		returnFunction.RowErrors = rowErrors
+		return returnFunction
+	}
+
+	logs.Logs("Insertion en masse réussie dans", t.TableName, inserted)
+	database.MarkWrite(ctx)
+	return ReturnFunction{
+		StatusCode:    200,
+		Message:       "Insert success",
+		InsertRecords: inserted,
+		RowErrors:     rowErrors,
+	}
+}
+
+// insertBatch insère un lot de lignes avec une seule requête INSERT multi-lignes
+// BeforeInsert et AfterInsert sont appelés pour le lot, AfterInsertRecord pour chaque ligne
+func (t Table) insertBatch(ctx context.Context, fields string, nbFields int, rows []*Record) (int64, error) {
+	if _, ok := t.Validator.(BeforeInsertHook); !ok {
+		if err := t.Validator.BeforeInsert(); err != nil {
+			return 0, validation(err)
+		}
+	}
+	values := make([]string, len(rows))
+	args := make([]interface{}, 0, len(rows)*nbFields)
+	for i, row := range rows {
+		values[i] = sqlFunctions.Placeholders(i*nbFields, nbFields, t.Dialect)
+		args = append(args, row.Values...)
+	}
+	query := "INSERT INTO " + t.TableName + " " + fields + " VALUES " + strings.Join(values, ", ")
+	logs.Logs(query, len(rows), "lignes")
+	result, err := t.Tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := t.Validator.(AfterInsertHook); !ok {
+		if err := t.Validator.AfterInsert(); err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+	for _, row := range rows {
+		rowResult := ReturnFunction{StatusCode: 200, Message: "Insert success", InsertRecords: 1}
+		if err := t.afterInsert(ctx, row, rowResult); err != nil {
+			return 0, err
+		}
+	}
+	return result.RowsAffected()
+}
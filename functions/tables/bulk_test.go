@@ -0,0 +1,109 @@
+package tables
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/jsavajols/goframework/functions/dialects"
+	"github.com/mattn/go-sqlite3"
+)
+
+// dollarSQLite dialecte sqlite dont les paramètres sont numérotés $1, $2... comme postgres
+type dollarSQLite struct{ dialects.SQLite }
+
+func (dollarSQLite) Name() string { return "sqlite_dollar" }
+
+func (dollarSQLite) DriverName() string { return "sqlite3_recorder" }
+
+func (dollarSQLite) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+// recorder driver sqlite3 qui conserve le texte des requêtes préparées
+type recorder struct {
+	sqlite3.SQLiteDriver
+	mutex   sync.Mutex
+	queries []string
+}
+
+func (r *recorder) Open(name string) (driver.Conn, error) {
+	conn, err := r.SQLiteDriver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &recorderConn{conn, r}, nil
+}
+
+func (r *recorder) reset() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	queries := r.queries
+	r.queries = nil
+	return queries
+}
+
+type recorderConn struct {
+	driver.Conn
+	recorder *recorder
+}
+
+func (c *recorderConn) Prepare(query string) (driver.Stmt, error) {
+	c.recorder.mutex.Lock()
+	c.recorder.queries = append(c.recorder.queries, query)
+	c.recorder.mutex.Unlock()
+	return c.Conn.Prepare(query)
+}
+
+var queries = &recorder{}
+
+func init() {
+	sql.Register("sqlite3_recorder", queries)
+	dialects.Register(dollarSQLite{})
+}
+
+// openRecorder ouvre une base sqlite en mémoire avec la table u (a, b)
+func openRecorder(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3_recorder", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec("create table u (a integer, b text)"); err != nil {
+		t.Fatal(err)
+	}
+	queries.reset()
+	return db
+}
+
+func TestBulkInsertPlaceholders(t *testing.T) {
+	t.Setenv("LOG", "false")
+	tests := []struct {
+		name  string
+		table Table
+	}{
+		{"dialecte de la table", Table{Dialect: "sqlite_dollar"}},
+		// Le dialecte vient de DB_DIALECT
+		{"dialecte de DB_DIALECT", Table{}},
+	}
+	t.Setenv("DB_DIALECT", "sqlite_dollar")
+	for _, test := range tests {
+		table := test.table
+		table.Db = openRecorder(t)
+		table.TableName = "u"
+		table.Validator = DefaultValidator{}
+		result := table.BulkInsert("a, b", [][]interface{}{{1, "x"}, {2, "y"}})
+		if result.StatusCode != 200 || result.InsertRecords != 2 {
+			t.Errorf("%s : BulkInsert = %d %s, %d lignes", test.name, result.StatusCode, result.ErrorMessage, result.InsertRecords)
+		}
+		want := "INSERT INTO u (a, b) VALUES ($1, $2), ($3, $4)"
+		found := false
+		for _, query := range queries.reset() {
+			found = found || query == want
+		}
+		if !found {
+			t.Errorf("%s : requête %q non exécutée", test.name, want)
+		}
+	}
+}
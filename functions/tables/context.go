@@ -0,0 +1,24 @@
+package tables
+
+import (
+	"context"
+)
+
+// Codes retournés dans ReturnFunction.StatusCode quand la requête est interrompue
+const (
+	// StatusCanceled le contexte a été annulé, par exemple à la déconnexion du client
+	StatusCanceled int32 = 499
+	// StatusTimeout le délai du contexte ou Table.Timeout a été dépassé
+	StatusTimeout int32 = 504
+)
+
+// withTimeout applique Table.Timeout au contexte s'il est défini
+func (t Table) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if t.Timeout > 0 {
+		return context.WithTimeout(ctx, t.Timeout)
+	}
+	return context.WithCancel(ctx)
+}
@@ -0,0 +1,299 @@
+package tables
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+// Types d'erreurs retournés par les opérations, à tester avec errors.Is sur ReturnFunction.Err :
+//
+//	if errors.Is(result.Err, tables.ErrConflict) { ... }
+var (
+	// ErrNotFound aucun enregistrement ne correspond au filtre
+	ErrNotFound = errors.New("Not found")
+	// ErrConflict clé en double, contrainte de clé étrangère, verrou mortel ou base occupée
+	ErrConflict = errors.New("Conflict")
+	// ErrValidation valeur refusée par le Validator, le schéma ou une contrainte (not null, check...)
+	ErrValidation = errors.New("Validation error")
+	// ErrReadOnly écriture dans une table en lecture seule
+	ErrReadOnly = errors.New("Table is read only")
+	// ErrTimeout délai du contexte, de Table.Timeout ou du serveur dépassé
+	ErrTimeout = errors.New("Timeout")
+	// ErrCanceled contexte annulé
+	ErrCanceled = errors.New("Canceled")
+	// ErrConnection base de données injoignable ou connexion refusée
+	ErrConnection = errors.New("Connection error")
+)
+
+// kind StatusCode et ErrorCode d'un type d'erreur
+type kind struct {
+	err    error
+	status int32
+	code   string
+}
+
+// kinds est une liste et non une map : une erreur non comparable (ValidationErrors...)
+// ne peut pas servir de clé
+var kinds = []kind{
+	{ErrNotFound, 404, "not_found"},
+	{ErrConflict, 409, "conflict"},
+	{ErrValidation, 422, "validation"},
+	{ErrReadOnly, 403, "read_only"},
+	{ErrTimeout, StatusTimeout, "timeout"},
+	{ErrCanceled, StatusCanceled, "canceled"},
+	{ErrConnection, 503, "connection"},
+}
+
+// kindOf retourne la description du type d'erreur err
+func kindOf(err error) (kind, bool) {
+	for _, k := range kinds {
+		if k.err == err {
+			return k, true
+		}
+	}
+	return kind{}, false
+}
+
+// errRejected requête refusée par CheckForSQLInjection
+var errRejected = &Error{Kind: ErrValidation, Err: errors.New("Requête refusée : motif suspect dans le filtre")}
+
+// Error erreur typée d'une opération : Kind est l'un des types Err..., nil si l'erreur
+// n'est pas reconnue, Code le code d'erreur du driver et Err l'erreur d'origine.
+// errors.Is teste Kind et errors.As permet de retrouver l'erreur du driver
+type Error struct {
+	Kind error
+	Code string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	if e.Kind != nil {
+		return e.Kind.Error()
+	}
+	return "Erreur inconnue"
+}
+
+func (e *Error) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	for _, err := range []error{e.Kind, e.Err} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// StatusCode retourne le StatusCode correspondant au type de l'erreur, 500 s'il n'est pas reconnu
+func (e *Error) StatusCode() int32 {
+	if k, ok := kindOf(e.Kind); ok {
+		return k.status
+	}
+	return 500
+}
+
+// ErrorCode retourne le code du type de l'erreur (not_found, conflict...), vide s'il n'est pas reconnu
+func (e *Error) ErrorCode() string {
+	k, _ := kindOf(e.Kind)
+	return k.code
+}
+
+// Classify retourne l'erreur typée correspondant à err, d'après les codes d'erreur
+// des drivers mysql, postgres, sqlite3 et sqlserver. Retourne nil si err est nil
+func Classify(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var typed *Error
+	if errors.As(err, &typed) {
+		if typed == err {
+			return typed
+		}
+		// Conserve le contexte ajouté autour de l'erreur typée
+		return &Error{Kind: typed.Kind, Code: typed.Code, Err: err}
+	}
+	if _, ok := kindOf(err); ok {
+		return &Error{Kind: err}
+	}
+	for _, k := range kinds {
+		if errors.Is(err, k.err) {
+			return &Error{Kind: k.err, Err: err}
+		}
+	}
+	kind, code := classify(err)
+	return &Error{Kind: kind, Code: code, Err: err}
+}
+
+// classify retourne le type et le code d'erreur du driver
+func classify(err error) (error, string) {
+	var mysqlErr *mysql.MySQLError
+	var pqErr *pq.Error
+	var sqliteErr sqlite3.Error
+	var mssqlErr mssql.Error
+	var netErr *net.OpError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return ErrCanceled, ""
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout, ""
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound, ""
+	case errors.As(err, &mysqlErr):
+		return mysqlKind(mysqlErr.Number), strconv.Itoa(int(mysqlErr.Number))
+	case errors.As(err, &pqErr):
+		return pqKind(pqErr.Code), string(pqErr.Code)
+	case errors.As(err, &sqliteErr):
+		return sqliteKind(sqliteErr), strconv.Itoa(int(sqliteErr.ExtendedCode))
+	case errors.As(err, &mssqlErr):
+		return mssqlKind(mssqlErr.Number), strconv.Itoa(int(mssqlErr.Number))
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, mysql.ErrInvalidConn), errors.As(err, &netErr):
+		return ErrConnection, ""
+	}
+	return nil, ""
+}
+
+func mysqlKind(number uint16) error {
+	switch number {
+	// Clé en double, clé étrangère, verrou mortel
+	case 1062, 1451, 1452, 1213:
+		return ErrConflict
+	// Colonne null, sans valeur par défaut, trop longue ou valeur incorrecte
+	case 1048, 1364, 1406, 1366, 3819:
+		return ErrValidation
+	// Délai d'attente du verrou, requête interrompue
+	case 1205, 3024:
+		return ErrTimeout
+	// Trop de connexions, accès refusé
+	case 1040, 1045, 1044:
+		return ErrConnection
+	}
+	return nil
+}
+
+func pqKind(code pq.ErrorCode) error {
+	switch code {
+	// unique_violation, foreign_key_violation, serialization_failure, deadlock_detected
+	case "23505", "23503", "40001", "40P01":
+		return ErrConflict
+	// not_null_violation, check_violation
+	case "23502", "23514":
+		return ErrValidation
+	// query_canceled (statement_timeout)
+	case "57014":
+		return ErrTimeout
+	// too_many_connections
+	case "53300":
+		return ErrConnection
+	}
+	switch code.Class() {
+	// Données invalides
+	case "22":
+		return ErrValidation
+	// Connexion, autorisation
+	case "08", "28":
+		return ErrConnection
+	}
+	return nil
+}
+
+func sqliteKind(err sqlite3.Error) error {
+	switch err.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey, sqlite3.ErrConstraintForeignKey:
+		return ErrConflict
+	case sqlite3.ErrConstraintNotNull, sqlite3.ErrConstraintCheck:
+		return ErrValidation
+	}
+	switch err.Code {
+	case sqlite3.ErrBusy, sqlite3.ErrLocked:
+		return ErrConflict
+	case sqlite3.ErrCantOpen:
+		return ErrConnection
+	}
+	return nil
+}
+
+func mssqlKind(number int32) error {
+	switch number {
+	// Clé en double, contrainte de clé étrangère, verrou mortel
+	case 2627, 2601, 547, 1205:
+		return ErrConflict
+	// Colonne null, valeur trop longue
+	case 515, 8152, 2628:
+		return ErrValidation
+	// Délai d'attente du verrou
+	case 1222:
+		return ErrTimeout
+	// Connexion refusée
+	case 18456, 4060:
+		return ErrConnection
+	}
+	return nil
+}
+
+// validation retourne err avec le type ErrValidation s'il n'est pas déjà typé
+// (erreurs du Validator et du contrôle du schéma)
+func validation(err error) error {
+	if e := Classify(err); e.Kind != nil {
+		return e
+	}
+	return &Error{Kind: ErrValidation, Err: err}
+}
+
+// failure retourne le résultat d'une opération en erreur, le StatusCode dépend du type de l'erreur
+func failure(ctx context.Context, message string, err error) ReturnFunction {
+	var r ReturnFunction
+	r.Message = message
+	r.setError(ctx, err)
+	return r
+}
+
+// setError renseigne StatusCode, ErrorMessage, ErrorCode, FieldErrors et Err à partir de l'erreur
+// Si ctx est terminé, l'erreur du driver est typée d'après le contexte : postgres signale par exemple
+// l'annulation d'une requête avec le code 57014, aussi utilisé pour statement_timeout
+func (r *ReturnFunction) setError(ctx context.Context, err error) {
+	e := contextError(ctx, Classify(err))
+	r.StatusCode = e.StatusCode()
+	r.ErrorMessage = e.Error()
+	r.ErrorCode = e.ErrorCode()
+	r.FieldErrors = fieldErrors(err)
+	r.Err = e
+}
+
+// contextError retourne e avec le type ErrCanceled ou ErrTimeout si ctx est terminé
+// et que l'erreur n'est pas une erreur de validation ou de conflit
+func contextError(ctx context.Context, e *Error) *Error {
+	if ctx == nil || ctx.Err() == nil {
+		return e
+	}
+	kind := ErrTimeout
+	if errors.Is(ctx.Err(), context.Canceled) {
+		kind = ErrCanceled
+	}
+	switch e.Kind {
+	case nil, ErrTimeout, ErrCanceled, ErrConnection:
+		if e.Kind == kind {
+			return e
+		}
+		return &Error{Kind: kind, Code: e.Code, Err: e.Err}
+	}
+	return e
+}
+
+// setHookError renseigne HookError avec l'erreur d'un hook After... survenue après l'écriture
+func (r *ReturnFunction) setHookError(err error) {
+	log.Error(err.Error())
+	r.HookError = err.Error()
+}
@@ -0,0 +1,122 @@
+package tables
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	mssql "github.com/microsoft/go-mssqldb"
+)
+
+func TestSetErrorContext(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	queryCanceled := &pq.Error{Code: "57014", Message: "canceling statement due to user request"}
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		err    error
+		status int32
+		kind   error
+	}{
+		{"statement_timeout", context.Background(), queryCanceled, StatusTimeout, ErrTimeout},
+		{"client déconnecté", canceled, queryCanceled, StatusCanceled, ErrCanceled},
+		{"délai dépassé", expired, queryCanceled, StatusTimeout, ErrTimeout},
+		{"erreur inconnue annulée", canceled, errors.New("driver: bad connection state"), StatusCanceled, ErrCanceled},
+		{"conflit conservé", canceled, &pq.Error{Code: "23505"}, 409, ErrConflict},
+	}
+	for _, test := range tests {
+		var r ReturnFunction
+		r.setError(test.ctx, test.err)
+		if r.StatusCode != test.status || !errors.Is(r.Err, test.kind) {
+			t.Errorf("%s : %d %v, attendu %d %v", test.name, r.StatusCode, r.Err, test.status, test.kind)
+		}
+	}
+	var r ReturnFunction
+	r.setError(canceled, queryCanceled)
+	if errors.Is(r.Err, ErrTimeout) {
+		t.Errorf("une requête annulée ne doit pas être aussi de type ErrTimeout")
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+		code string
+	}{
+		{"mysql clé en double", &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}, ErrConflict, "1062"},
+		{"mysql colonne null", &mysql.MySQLError{Number: 1048}, ErrValidation, "1048"},
+		{"mysql verrou", &mysql.MySQLError{Number: 1205}, ErrTimeout, "1205"},
+		{"mysql accès refusé", &mysql.MySQLError{Number: 1045}, ErrConnection, "1045"},
+		{"mysql inconnue", &mysql.MySQLError{Number: 1146}, nil, "1146"},
+		{"postgres unique", &pq.Error{Code: "23505"}, ErrConflict, "23505"},
+		{"postgres not null", &pq.Error{Code: "23502"}, ErrValidation, "23502"},
+		{"postgres classe 22", &pq.Error{Code: "22001"}, ErrValidation, "22001"},
+		{"postgres classe 08", &pq.Error{Code: "08006"}, ErrConnection, "08006"},
+		{"postgres 57014", &pq.Error{Code: "57014"}, ErrTimeout, "57014"},
+		{"sqlite unique", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}, ErrConflict, "2067"},
+		{"sqlite not null", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintNotNull}, ErrValidation, "1299"},
+		{"sqlite occupée", sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrBusyRecovery}, ErrConflict, "261"},
+		{"mssql clé en double", mssql.Error{Number: 2627}, ErrConflict, "2627"},
+		{"aucune ligne", sql.ErrNoRows, ErrNotFound, ""},
+		{"contexte annulé", context.Canceled, ErrCanceled, ""},
+		{"délai dépassé", context.DeadlineExceeded, ErrTimeout, ""},
+		{"connexion perdue", driver.ErrBadConn, ErrConnection, ""},
+		{"erreur du driver enveloppée", fmt.Errorf("ligne 3 : %w", &pq.Error{Code: "23505"}), ErrConflict, "23505"},
+		{"type sans erreur d'origine", ErrReadOnly, ErrReadOnly, ""},
+		{"type enveloppé", fmt.Errorf("commande 12 : %w", ErrNotFound), ErrNotFound, ""},
+		{"erreur non comparable", ValidationErrors{{Field: "email", Rule: "required"}}, nil, ""},
+		{"erreur inconnue", errors.New("inconnue"), nil, ""},
+	}
+	for _, test := range tests {
+		e := Classify(test.err)
+		if e.Kind != test.kind || e.Code != test.code {
+			t.Errorf("%s : Classify = %v %q, attendu %v %q", test.name, e.Kind, e.Code, test.kind, test.code)
+		}
+		if test.kind != nil && !errors.Is(e, test.kind) {
+			t.Errorf("%s : errors.Is(%v) faux", test.name, test.kind)
+		}
+	}
+	if Classify(nil) != nil {
+		t.Errorf("Classify(nil) doit retourner nil")
+	}
+}
+
+func TestClassifyWrapped(t *testing.T) {
+	inner := &Error{Kind: ErrConflict, Code: "1062", Err: &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}}
+	tests := []struct {
+		name    string
+		err     error
+		message string
+		status  int32
+		same    bool
+	}{
+		{"erreur typée", inner, "Error 1062: Duplicate entry", 409, true},
+		{"contexte conservé", fmt.Errorf("ligne 3 : %w", inner), "ligne 3 : Error 1062: Duplicate entry", 409, false},
+		{"type enveloppé", fmt.Errorf("commande 12 : %w", ErrNotFound), "commande 12 : Not found", 404, false},
+	}
+	for _, test := range tests {
+		e := Classify(test.err)
+		if e.Error() != test.message || e.StatusCode() != test.status {
+			t.Errorf("%s : %q %d, attendu %q %d", test.name, e.Error(), e.StatusCode(), test.message, test.status)
+		}
+		if (e == inner) != test.same {
+			t.Errorf("%s : erreur d'origine retournée %v, attendu %v", test.name, e == inner, test.same)
+		}
+		var mysqlErr *mysql.MySQLError
+		if test.status == 409 && !errors.As(e, &mysqlErr) {
+			t.Errorf("%s : l'erreur du driver n'est plus accessible par errors.As", test.name)
+		}
+	}
+}
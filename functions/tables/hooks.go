@@ -0,0 +1,156 @@
+package tables
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Record enregistrement transmis aux hooks, que les hooks Before... peuvent modifier
+// avant l'envoi de la requête (horodatage, normalisation des valeurs...)
+type Record struct {
+	// Fields colonnes écrites et Values leurs valeurs, vides pour une suppression
+	Fields []string
+	Values []interface{}
+	// Filter clause where et Args ses paramètres, pour une mise à jour ou une suppression
+	Filter string
+	Args   []interface{}
+	// Struct pointeur sur la structure de InsertStruct ou UpdateStruct, nil sinon
+	// Elle est mise à jour par Set, ses autres modifications ne sont pas écrites
+	Struct interface{}
+}
+
+// Get retourne la valeur de la colonne et indique si elle fait partie de l'enregistrement
+func (r *Record) Get(field string) (interface{}, bool) {
+	if i := r.index(field); i >= 0 {
+		return r.Values[i], true
+	}
+	return nil, false
+}
+
+// Set remplace la valeur de la colonne, ou l'ajoute à l'enregistrement,
+// et met à jour le champ correspondant de Struct
+func (r *Record) Set(field string, value interface{}) error {
+	if i := r.index(field); i >= 0 {
+		r.Values[i] = value
+	} else {
+		r.Fields = append(r.Fields, field)
+		r.Values = append(r.Values, value)
+	}
+	if r.Struct == nil {
+		return nil
+	}
+	record, err := structValue(r.Struct)
+	if err != nil {
+		return err
+	}
+	if f := fieldsByColumn(record.Type(), []string{field})[0]; f != nil {
+		if err := assignValue(record.FieldByIndex(f.Index), value); err != nil {
+			return fmt.Errorf("colonne %s : %w", field, err)
+		}
+	}
+	return nil
+}
+
+func (r *Record) index(field string) int {
+	for i, name := range r.Fields {
+		if strings.EqualFold(name, field) && i < len(r.Values) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Hooks optionnels du Validator : s'il les implémente, ils sont appelés à la place
+// des méthodes BeforeInsert, AfterInsert... de l'interface Validator.
+// Une erreur d'un hook Before... annule l'opération (StatusCode 422 sauf erreur typée),
+// celle d'un hook After... annule la transaction en cours et est retournée dans ReturnFunction.
+// Hors transaction, l'écriture a déjà eu lieu : StatusCode et les compteurs sont conservés
+// et l'erreur est retournée dans ReturnFunction.HookError
+type (
+	// BeforeInsertHook reçoit l'enregistrement à insérer
+	BeforeInsertHook interface {
+		BeforeInsertRecord(ctx context.Context, record *Record) error
+	}
+	// AfterInsertHook reçoit l'enregistrement inséré et le résultat (LastInsertId...)
+	AfterInsertHook interface {
+		AfterInsertRecord(ctx context.Context, record *Record, result ReturnFunction) error
+	}
+	// BeforeUpdateHook reçoit les colonnes et valeurs à mettre à jour et le filtre
+	BeforeUpdateHook interface {
+		BeforeUpdateRecord(ctx context.Context, record *Record) error
+	}
+	// AfterUpdateHook reçoit l'enregistrement mis à jour et le résultat (UpdateRecords...)
+	AfterUpdateHook interface {
+		AfterUpdateRecord(ctx context.Context, record *Record, result ReturnFunction) error
+	}
+	// BeforeDeleteHook reçoit le filtre de la suppression
+	BeforeDeleteHook interface {
+		BeforeDeleteRecord(ctx context.Context, record *Record) error
+	}
+	// AfterDeleteHook reçoit le filtre et le résultat (DeleteRecords...)
+	AfterDeleteHook interface {
+		AfterDeleteRecord(ctx context.Context, record *Record, result ReturnFunction) error
+	}
+)
+
+// beforeInsert appelle BeforeInsertRecord ou à défaut BeforeInsert
+func (t Table) beforeInsert(ctx context.Context, record *Record) error {
+	if hook, ok := t.Validator.(BeforeInsertHook); ok {
+		return hook.BeforeInsertRecord(ctx, record)
+	}
+	return t.Validator.BeforeInsert()
+}
+
+// afterInsert appelle AfterInsertRecord ou à défaut AfterInsert
+func (t Table) afterInsert(ctx context.Context, record *Record, result ReturnFunction) error {
+	if hook, ok := t.Validator.(AfterInsertHook); ok {
+		return hook.AfterInsertRecord(ctx, record, result)
+	}
+	return t.Validator.AfterInsert()
+}
+
+// beforeUpdate appelle BeforeUpdateRecord ou à défaut BeforeUpdate
+func (t Table) beforeUpdate(ctx context.Context, record *Record) error {
+	if hook, ok := t.Validator.(BeforeUpdateHook); ok {
+		return hook.BeforeUpdateRecord(ctx, record)
+	}
+	return t.Validator.BeforeUpdate(record.Values)
+}
+
+// afterUpdate appelle AfterUpdateRecord ou à défaut AfterUpdate
+func (t Table) afterUpdate(ctx context.Context, record *Record, result ReturnFunction) error {
+	if hook, ok := t.Validator.(AfterUpdateHook); ok {
+		return hook.AfterUpdateRecord(ctx, record, result)
+	}
+	if !t.Validator.AfterUpdate() {
+		return errors.New("Échec de AfterUpdate")
+	}
+	return nil
+}
+
+// beforeDelete appelle BeforeDeleteRecord ou à défaut BeforeDelete
+func (t Table) beforeDelete(ctx context.Context, record *Record) error {
+	if hook, ok := t.Validator.(BeforeDeleteHook); ok {
+		return hook.BeforeDeleteRecord(ctx, record)
+	}
+	return t.Validator.BeforeDelete()
+}
+
+// afterDelete appelle AfterDeleteRecord ou à défaut AfterDelete
+func (t Table) afterDelete(ctx context.Context, record *Record, result ReturnFunction) error {
+	if hook, ok := t.Validator.(AfterDeleteHook); ok {
+		return hook.AfterDeleteRecord(ctx, record, result)
+	}
+	if !t.Validator.AfterDelete() {
+		return errors.New("Échec de AfterDelete")
+	}
+	return nil
+}
+
+// sameFields indique si les deux listes contiennent les mêmes colonnes dans le même ordre
+func sameFields(a, b []string) bool {
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,84 @@
+package tables
+
+import (
+	"context"
+	"reflect"
+
+	sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+
+	logs "github.com/jsavajols/goframework/functions/logs"
+)
+
+// Pagination informations de pagination d'une lecture avec limit, ou de toute lecture si CountTotal est vrai
+type Pagination struct {
+	// Total nombre de lignes correspondant au filtre, -1 si CountTotal est faux
+	Total    int64 `json:"total"`
+	Page     int   `json:"page"`
+	PageSize int   `json:"pageSize"`
+	HasNext  bool  `json:"hasNext"`
+	// NextCursor start de la page suivante, ou Keyset.After de la page suivante pour GetKeyset
+	NextCursor interface{} `json:"nextCursor,omitempty"`
+}
+
+// pageFetch nombre de lignes à lire pour une page de limit lignes :
+// une de plus pour savoir s'il y a une page suivante quand le total n'est pas compté
+func (t Table) pageFetch(limit int) int {
+	if limit > 0 && !t.CountTotal {
+		return limit + 1
+	}
+	return limit
+}
+
+// paginate calcule la pagination des lignes lues avec pageFetch et retire la ligne supplémentaire
+// count retourne la requête de comptage, exécutée seulement si le total n'est pas déduit de la page
+func (t Table) paginate(ctx context.Context, db executor, rows interface{}, records, start, limit int, count func() (string, []interface{})) (*Pagination, interface{}, int, error) {
+	if limit <= 0 && !t.CountTotal {
+		return nil, rows, records, nil
+	}
+	pagination := &Pagination{Total: -1, Page: 1, PageSize: limit}
+	if limit > 0 {
+		pagination.Page = start/limit + 1
+	} else {
+		pagination.PageSize = records
+	}
+	if limit > 0 && records > limit {
+		rows = reflect.ValueOf(rows).Slice(0, limit).Interface()
+		records = limit
+		pagination.HasNext = true
+	}
+	if t.CountTotal {
+		// Page incomplète : le total se déduit de la page sans requête de comptage
+		if limit <= 0 || (records < limit && (records > 0 || start == 0)) {
+			pagination.Total = int64(start + records)
+		} else {
+			query, args := count()
+			if query == "" {
+				return nil, rows, records, errRejected
+			}
+			logs.Logs(query, args)
+			if err := db.QueryRowContext(ctx, query, args...).Scan(&pagination.Total); err != nil {
+				return nil, rows, records, err
+			}
+		}
+		pagination.HasNext = int64(start+records) < pagination.Total
+	}
+	if pagination.HasNext {
+		pagination.NextCursor = start + limit
+	}
+	return pagination, rows, records, nil
+}
+
+// buildCountQuery requête de comptage des lignes correspondant au filtre de GetWhere
+func (t Table) buildCountQuery(filter string) string {
+	query := ""
+	if t.Source != "" {
+		query = "select count(*) from (" + t.Source + whereClause(filter) + ") count_query"
+	} else {
+		query = "select count(*) from " + t.TableName + whereClause(filter)
+	}
+	query = t.dialect().Translate(query)
+	if sqlFunctions.CheckForSQLInjection(query) {
+		return ""
+	}
+	return sqlFunctions.Rebind(query, t.Dialect)
+}
@@ -0,0 +1,70 @@
+package tables
+
+import (
+	"context"
+
+	con "github.com/jsavajols/goframework/const"
+	"github.com/jsavajols/goframework/functions/query"
+
+	logs "github.com/jsavajols/goframework/functions/logs"
+)
+
+// Select démarre une requête sur la table, à exécuter avec GetQuery :
+// t.GetQuery(t.Select("id", "name").Where(query.Eq("status", "open")).OrderBy("name").Limit(20))
+func (t Table) Select(columns ...string) *query.Builder {
+	return query.Select(columns...).From(t.TableName)
+}
+
+// GetQuery retourne les enregistrements de la requête construite avec Select
+func (t Table) GetQuery(q *query.Builder) ReturnFunction {
+	return t.GetQueryContext(context.Background(), q)
+}
+
+// GetQueryContext version de GetQuery interrompue à l'annulation de ctx
+func (t Table) GetQueryContext(ctx context.Context, q *query.Builder) ReturnFunction {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+	db, dialect, err := t.readConn(ctx)
+	if err == nil {
+		err = t.bindTx()
+	}
+	if err != nil {
+		returnFunction := failure(ctx, "Get error", err)
+		returnFunction.Rows = make([]map[string]interface{}, 0)
+		return returnFunction
+	}
+	t.Dialect = dialect
+
+	errorMessage := ""
+	// Limite au nombre de lignes maximum defini dans const/const.go
+	limit, start := q.Limits()
+	if limit > con.ROWS_LIMIT {
+		errorMessage = "Limit too high"
+		limit = con.ROWS_LIMIT
+	}
+	// Copie de la requête pour ne pas modifier celle de l'appelant
+	page := *q
+	page.Limit(t.pageFetch(limit))
+	sql, args := page.Build(t.Dialect)
+	logs.Logs(sql, args)
+	tableData, getRecords, err := t.queryRows(ctx, db, sql, args)
+	var pagination *Pagination
+	if err == nil {
+		pagination, tableData, getRecords, err = t.paginate(ctx, db, tableData, getRecords, start, limit, func() (string, []interface{}) {
+			return q.BuildCount(t.Dialect)
+		})
+	}
+	if err != nil {
+		returnFunction := failure(ctx, "Get error", err)
+		returnFunction.Rows = make([]map[string]interface{}, 0)
+		return returnFunction
+	}
+	return ReturnFunction{
+		StatusCode:   200,
+		Message:      "Get success",
+		ErrorMessage: errorMessage,
+		GetRecords:   getRecords,
+		Rows:         tableData,
+		Pagination:   pagination,
+	}
+}
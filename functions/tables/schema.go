@@ -0,0 +1,69 @@
+package tables
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/jsavajols/goframework/functions/database"
+)
+
+// schemas descriptions des tables déjà lues
+var schemas sync.Map
+
+// Schema retourne la description de la table, lue une seule fois par connexion
+func (t Table) Schema(ctx context.Context) (database.TableSchema, error) {
+	key := t.schemaKey()
+	if schema, ok := schemas.Load(key); ok {
+		return schema.(database.TableSchema), nil
+	}
+	db, dialect, err := t.conn()
+	if err != nil {
+		return database.TableSchema{}, err
+	}
+	schema, err := database.Describe(ctx, db, dialect, t.TableName)
+	if err != nil {
+		return database.TableSchema{}, err
+	}
+	schemas.Store(key, schema)
+	return schema, nil
+}
+
+// RefreshSchema oublie la description de la table, relue au prochain appel de Schema
+func (t Table) RefreshSchema() {
+	schemas.Delete(t.schemaKey())
+}
+
+// ClearSchemas oublie les descriptions de toutes les tables, par exemple après une migration
+func ClearSchemas() {
+	schemas.Range(func(key, _ interface{}) bool {
+		schemas.Delete(key)
+		return true
+	})
+}
+
+func (t Table) schemaKey() string {
+	return fmt.Sprintf("%p|%p|%s|%s|%s|%s", t.Db, t.Cluster, t.Connection, t.Dialect, t.Database, t.TableName)
+}
+
+// checkSchema vérifie les champs et valeurs avec la description de la table si CheckSchema est vrai
+func (t Table) checkSchema(ctx context.Context, fields []string, values []interface{}, insert bool) error {
+	if !t.CheckSchema {
+		return nil
+	}
+	schema, err := t.Schema(ctx)
+	if err != nil {
+		return err
+	}
+	return schema.ValidateValues(fields, values, insert)
+}
+
+// splitFields retourne les colonnes d'une liste au format de Insert : "(a, b, c)"
+func splitFields(fields string) []string {
+	columns := strings.Split(strings.Trim(fields, "() "), ",")
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+	return columns
+}
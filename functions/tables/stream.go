@@ -0,0 +1,321 @@
+package tables
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	con "github.com/jsavajols/goframework/const"
+	sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+
+	"github.com/gofiber/fiber/v2/log"
+	logs "github.com/jsavajols/goframework/functions/logs"
+)
+
+// Cursor parcourt les lignes d'une requête une par une, sans les charger en mémoire
+// Il doit être fermé avec Close
+type Cursor struct {
+	stmt     *sql.Stmt
+	rows     *sql.Rows
+	cancel   context.CancelFunc
+	columns  []string
+	values   []interface{}
+	scanArgs []interface{}
+	typ      reflect.Type
+	fields   []*structField
+	err      error
+}
+
+// Cursor exécute la requête et retourne un curseur sur ses lignes, sans limite de nombre
+// Les paramètres sont ceux de GetWhere, sans start ni limit. Le Timeout de la table
+// s'applique au parcours complet, jusqu'à Close :
+//
+//	cursor, err := t.Cursor(ctx, "id, name", "status = ?", []interface{}{"open"}, "id")
+//	defer cursor.Close()
+//	for cursor.Next() {
+//		var user User
+//		cursor.Scan(&user)
+//	}
+//	err = cursor.Err()
+func (t Table) Cursor(ctx context.Context, fields string, filter string, args []interface{}, sort string) (*Cursor, error) {
+	ctx, cancel := t.withTimeout(ctx)
+	db, dialect, err := t.readConn(ctx)
+	if err == nil {
+		err = t.bindTx()
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	t.Dialect = dialect
+
+	if fields == "" {
+		fields = "*"
+	}
+	if filter == "" {
+		filter = "1 = 1"
+	}
+	if sort != "" {
+		sort = " order by " + sort
+	}
+	query := t.buildQuery(fields, filter, sort, "")
+	if query == "" {
+		cancel()
+		return nil, errRejected
+	}
+	query = sqlFunctions.Rebind(query, t.Dialect)
+	logs.Logs(query, args)
+
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		stmt.Close()
+		cancel()
+		return nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		stmt.Close()
+		cancel()
+		return nil, err
+	}
+	c := &Cursor{stmt: stmt, rows: rows, cancel: cancel, columns: columns}
+	c.values = make([]interface{}, len(columns))
+	c.scanArgs = make([]interface{}, len(columns))
+	for i := range c.values {
+		c.scanArgs[i] = &c.values[i]
+	}
+	return c, nil
+}
+
+// Next passe à la ligne suivante, faux à la fin des lignes ou en cas d'erreur (voir Err)
+func (c *Cursor) Next() bool {
+	if c.err != nil || !c.rows.Next() {
+		return false
+	}
+	if err := c.rows.Scan(c.scanArgs...); err != nil {
+		c.err = err
+		return false
+	}
+	return true
+}
+
+// Columns retourne les colonnes de la requête
+func (c *Cursor) Columns() []string {
+	return c.columns
+}
+
+// Map retourne la ligne courante, les []byte sont convertis en chaines comme dans Get
+func (c *Cursor) Map() map[string]interface{} {
+	entry := make(map[string]interface{}, len(c.columns))
+	for i, column := range c.columns {
+		if b, ok := c.values[i].([]byte); ok {
+			entry[column] = string(b)
+		} else {
+			entry[column] = c.values[i]
+		}
+	}
+	return entry
+}
+
+// Scan copie la ligne courante dans dest : un pointeur sur une structure
+// (colonnes associées comme pour Table.Struct) ou sur un map[string]interface{}
+func (c *Cursor) Scan(dest interface{}) error {
+	if m, ok := dest.(*map[string]interface{}); ok {
+		*m = c.Map()
+		return nil
+	}
+	value := reflect.ValueOf(dest)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Scan attend un pointeur sur une structure, reçu %T", dest)
+	}
+	record := value.Elem()
+	if c.typ != record.Type() {
+		c.typ = record.Type()
+		c.fields = fieldsByColumn(c.typ, c.columns)
+	}
+	for i, field := range c.fields {
+		if field == nil {
+			continue
+		}
+		if err := assignValue(record.FieldByIndex(field.Index), c.values[i]); err != nil {
+			return fmt.Errorf("colonne %s : %w", c.columns[i], err)
+		}
+	}
+	return nil
+}
+
+// Err retourne l'erreur survenue pendant le parcours
+func (c *Cursor) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.rows.Err()
+}
+
+// Close libère la requête, à appeler même si le parcours est terminé
+func (c *Cursor) Close() error {
+	err := c.rows.Close()
+	c.stmt.Close()
+	c.cancel()
+	return err
+}
+
+// Each appelle fn pour chaque ligne, lue une par une sans limite de nombre
+// row est une valeur de Struct si elle est définie, sinon un map[string]interface{}
+// Une erreur de fn arrête le parcours. GetRecords contient le nombre de lignes traitées
+func (t Table) Each(ctx context.Context, fields string, filter string, args []interface{}, sort string, fn func(row interface{}) error) ReturnFunction {
+	var typ reflect.Type
+	if t.Struct != nil {
+		var err error
+		if typ, err = structType(t.Struct); err != nil {
+			return failure(ctx, "Get error", err)
+		}
+	}
+	cursor, err := t.Cursor(ctx, fields, filter, args, sort)
+	if err != nil {
+		log.Error(err.Error())
+		return failure(ctx, "Get error", err)
+	}
+	defer cursor.Close()
+
+	records := 0
+	for cursor.Next() {
+		var row interface{}
+		if typ != nil {
+			record := reflect.New(typ)
+			if err = cursor.Scan(record.Interface()); err != nil {
+				break
+			}
+			row = record.Elem().Interface()
+		} else {
+			row = cursor.Map()
+		}
+		if err = fn(row); err != nil {
+			break
+		}
+		records++
+	}
+	if err == nil {
+		err = cursor.Err()
+	}
+	if err != nil {
+		log.Error(err.Error())
+		returnFunction := failure(ctx, "Get error", err)
+		returnFunction.GetRecords = records
+		return returnFunction
+	}
+	return ReturnFunction{StatusCode: 200, Message: "Get success", GetRecords: records}
+}
+
+// Keyset pagination par clé : chaque page commence après la dernière valeur de Column
+// de la page précédente, sans offset, ce qui permet de parcourir de grandes tables
+type Keyset struct {
+	// Column colonne unique de tri, PrimaryKey de la table par défaut
+	Column string
+	// After valeur de Column de la dernière ligne de la page précédente, nil pour la première page
+	After interface{}
+	// Desc parcourt les valeurs de Column en ordre décroissant
+	Desc bool
+	// Limit nombre de lignes par page, con.ROWS_LIMIT au maximum et par défaut
+	Limit int
+}
+
+// GetKeyset retourne une page de lignes et la valeur de Keyset.After de la page suivante,
+// nil s'il n'y en a plus. Column doit faire partie des champs retournés
+func (t Table) GetKeyset(ctx context.Context, fields string, filter string, args []interface{}, keyset Keyset) (ReturnFunction, interface{}) {
+	if keyset.Column == "" {
+		keyset.Column = t.PrimaryKey
+	}
+	if keyset.Column == "" {
+		returnFunction := failure(ctx, "Get error", errors.New("Colonne de pagination non définie"))
+		returnFunction.Rows = make([]map[string]interface{}, 0)
+		return returnFunction, nil
+	}
+	if keyset.Limit <= 0 || keyset.Limit > con.ROWS_LIMIT {
+		keyset.Limit = con.ROWS_LIMIT
+	}
+	if filter == "" {
+		filter = "1 = 1"
+	}
+	filter = "(" + filter + ")"
+	queryArgs := append([]interface{}{}, args...)
+	operator, direction := " > ?", " asc"
+	if keyset.Desc {
+		operator, direction = " < ?", " desc"
+	}
+	if keyset.After != nil {
+		filter += " and " + keyset.Column + operator
+		queryArgs = append(queryArgs, keyset.After)
+	}
+
+	// Le total n'a pas de sens pour une page qui commence après une clé
+	t.CountTotal = false
+	result := t.GetWhereContext(ctx, fields, filter, queryArgs, keyset.Column+direction, 0, keyset.Limit)
+	if result.StatusCode != 200 || !result.Pagination.HasNext {
+		return result, nil
+	}
+	next, err := lastKey(result.Rows, keyset.Column)
+	if err != nil {
+		returnFunction := failure(ctx, "Get error", err)
+		returnFunction.Rows = make([]map[string]interface{}, 0)
+		return returnFunction, nil
+	}
+	result.Pagination.NextCursor = next
+	return result, next
+}
+
+// Walk parcourt toutes les pages de GetKeyset à partir de keyset.After et appelle fn pour chacune
+// Une page en erreur ou une erreur de fn arrête le parcours
+func (t Table) Walk(ctx context.Context, fields string, filter string, args []interface{}, keyset Keyset, fn func(page ReturnFunction) error) error {
+	for {
+		page, next := t.GetKeyset(ctx, fields, filter, args, keyset)
+		if page.StatusCode != 200 {
+			if page.Err != nil {
+				return page.Err
+			}
+			return errors.New(page.ErrorMessage)
+		}
+		if page.GetRecords > 0 {
+			if err := fn(page); err != nil {
+				return err
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		keyset.After = next
+	}
+}
+
+// lastKey retourne la valeur de column dans la dernière ligne de rows ([]map ou []Struct)
+func lastKey(rows interface{}, column string) (interface{}, error) {
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice || value.Len() == 0 {
+		return nil, nil
+	}
+	last := value.Index(value.Len() - 1)
+	if m, ok := last.Interface().(map[string]interface{}); ok {
+		if v, ok := m[column]; ok {
+			return v, nil
+		}
+		for key, v := range m {
+			if strings.EqualFold(key, column) {
+				return v, nil
+			}
+		}
+	} else if last.Kind() == reflect.Struct {
+		if field := fieldsByColumn(last.Type(), []string{column})[0]; field != nil {
+			return last.FieldByIndex(field.Index).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("La colonne %s doit faire partie des champs retournés", column)
+}
@@ -0,0 +1,376 @@
+package tables
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// structField décrit un champ de structure associé à une colonne de la table
+// Le tag db est prioritaire sur le tag json, à défaut le nom du champ est utilisé :
+// `db:"id,pk,autoincrement"`, `db:"name,omitempty"` ou `db:"-"` pour ignorer le champ
+type structField struct {
+	Column        string
+	Index         []int
+	OmitEmpty     bool
+	PrimaryKey    bool
+	AutoIncrement bool
+}
+
+var structFieldsCache sync.Map
+
+// timeLayouts formats de dates acceptés quand le driver retourne une chaine
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"15:04:05",
+}
+
+// structType retourne le type de structure décrit par s (structure, pointeur ou slice de structures)
+func structType(s interface{}) (reflect.Type, error) {
+	typ := reflect.TypeOf(s)
+	for typ != nil && (typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice) {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Struct doit être une structure : %T", s)
+	}
+	return typ, nil
+}
+
+// structFields retourne les champs de la structure associés à des colonnes,
+// y compris ceux des structures imbriquées anonymes
+func structFields(typ reflect.Type) []structField {
+	if cached, ok := structFieldsCache.Load(typ); ok {
+		return cached.([]structField)
+	}
+	fields := make([]structField, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag, ok := f.Tag.Lookup("db")
+		if !ok {
+			tag = f.Tag.Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		if f.Anonymous && tag == "" && f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Time{}) {
+			for _, sub := range structFields(f.Type) {
+				sub.Index = append([]int{i}, sub.Index...)
+				fields = append(fields, sub)
+			}
+			continue
+		}
+		options := strings.Split(tag, ",")
+		field := structField{Column: strings.TrimSpace(options[0]), Index: []int{i}}
+		if field.Column == "" {
+			field.Column = f.Name
+		}
+		for _, option := range options[1:] {
+			switch strings.TrimSpace(option) {
+			case "omitempty":
+				field.OmitEmpty = true
+			case "pk":
+				field.PrimaryKey = true
+			case "autoincrement":
+				field.AutoIncrement = true
+			}
+		}
+		fields = append(fields, field)
+	}
+	structFieldsCache.Store(typ, fields)
+	return fields
+}
+
+// fieldsByColumn associe à chaque colonne le champ de la structure correspondant
+// La correspondance est d'abord exacte puis insensible à la casse
+func fieldsByColumn(typ reflect.Type, columns []string) []*structField {
+	fields := structFields(typ)
+	matched := make([]*structField, len(columns))
+	for i, column := range columns {
+		for j := range fields {
+			if fields[j].Column == column {
+				matched[i] = &fields[j]
+				break
+			}
+		}
+		if matched[i] != nil {
+			continue
+		}
+		for j := range fields {
+			if strings.EqualFold(fields[j].Column, column) {
+				matched[i] = &fields[j]
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// fetchStructs lit les lignes dans un slice de structures du type typ ([]typ)
+// Les colonnes sans champ correspondant sont ignorées
+func (t Table) fetchStructs(rows *sql.Rows, typ reflect.Type) (interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	fields := fieldsByColumn(typ, columns)
+
+	values := make([]interface{}, len(columns))
+	scanArgs := make([]interface{}, len(columns))
+	for i := range values {
+		scanArgs[i] = &values[i]
+	}
+	result := reflect.MakeSlice(reflect.SliceOf(typ), 0, 0)
+	for rows.Next() {
+		if err := rows.Scan(scanArgs...); err != nil {
+			return nil, err
+		}
+		record := reflect.New(typ).Elem()
+		for i, field := range fields {
+			if field == nil {
+				continue
+			}
+			if err := assignValue(record.FieldByIndex(field.Index), values[i]); err != nil {
+				return nil, fmt.Errorf("colonne %s : %w", columns[i], err)
+			}
+		}
+		result = reflect.Append(result, record)
+	}
+	return result.Interface(), rows.Err()
+}
+
+// assignValue convertit la valeur retournée par le driver dans le type du champ dst
+// Une valeur NULL donne la valeur zéro du champ, ou nil pour un pointeur
+func assignValue(dst reflect.Value, src interface{}) error {
+	if scanner, ok := dst.Addr().Interface().(sql.Scanner); ok {
+		return scanner.Scan(src)
+	}
+	if src == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+	if dst.Kind() == reflect.Ptr {
+		value := reflect.New(dst.Type().Elem())
+		if err := assignValue(value.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(value)
+		return nil
+	}
+	if b, ok := src.([]byte); ok {
+		if dst.Kind() == reflect.Slice && dst.Type().Elem().Kind() == reflect.Uint8 {
+			dst.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+		src = string(b)
+	}
+
+	if _, ok := dst.Interface().(time.Time); ok {
+		switch v := src.(type) {
+		case time.Time:
+			dst.Set(reflect.ValueOf(v))
+			return nil
+		case string:
+			for _, layout := range timeLayouts {
+				if parsed, err := time.Parse(layout, v); err == nil {
+					dst.Set(reflect.ValueOf(parsed))
+					return nil
+				}
+			}
+			return fmt.Errorf("date invalide : %s", v)
+		}
+		return fmt.Errorf("conversion impossible de %T en time.Time", src)
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		switch v := src.(type) {
+		case string:
+			dst.SetString(v)
+		case time.Time:
+			dst.SetString(v.Format("2006-01-02 15:04:05"))
+		default:
+			dst.SetString(fmt.Sprint(v))
+		}
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v := src.(type) {
+		case int64:
+			dst.SetInt(v)
+		case float64:
+			dst.SetInt(int64(v))
+		case bool:
+			if v {
+				dst.SetInt(1)
+			} else {
+				dst.SetInt(0)
+			}
+		case string:
+			parsed, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return err
+			}
+			dst.SetInt(parsed)
+		default:
+			return fmt.Errorf("conversion impossible de %T en %s", src, dst.Type())
+		}
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v := src.(type) {
+		case int64:
+			dst.SetUint(uint64(v))
+		case float64:
+			dst.SetUint(uint64(v))
+		case string:
+			parsed, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return err
+			}
+			dst.SetUint(parsed)
+		default:
+			return fmt.Errorf("conversion impossible de %T en %s", src, dst.Type())
+		}
+		return nil
+	case reflect.Float32, reflect.Float64:
+		switch v := src.(type) {
+		case float64:
+			dst.SetFloat(v)
+		case int64:
+			dst.SetFloat(float64(v))
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return err
+			}
+			dst.SetFloat(parsed)
+		default:
+			return fmt.Errorf("conversion impossible de %T en %s", src, dst.Type())
+		}
+		return nil
+	case reflect.Bool:
+		switch v := src.(type) {
+		case bool:
+			dst.SetBool(v)
+		case int64:
+			dst.SetBool(v != 0)
+		case string:
+			parsed, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err != nil {
+				return err
+			}
+			dst.SetBool(parsed)
+		default:
+			return fmt.Errorf("conversion impossible de %T en %s", src, dst.Type())
+		}
+		return nil
+	}
+
+	value := reflect.ValueOf(src)
+	if value.Type().ConvertibleTo(dst.Type()) {
+		dst.Set(value.Convert(dst.Type()))
+		return nil
+	}
+	return fmt.Errorf("conversion impossible de %T en %s", src, dst.Type())
+}
+
+// InsertStruct insère l'enregistrement record, pointeur vers une structure taguée
+// Les champs autoincrement à zéro et les champs omitempty vides ne sont pas insérés,
+// la clé générée est ensuite recopiée dans le champ autoincrement (ou pk) de record
+func (t *Table) InsertStruct(record interface{}) ReturnFunction {
+	return t.InsertStructContext(context.Background(), record)
+}
+
+// InsertStructContext version de InsertStruct interrompue à l'annulation de ctx
+func (t *Table) InsertStructContext(ctx context.Context, record interface{}) ReturnFunction {
+	value, err := structValue(record)
+	if err != nil {
+		return failure(ctx, "Insert error", err)
+	}
+	var columns []string
+	var values []interface{}
+	// La clé est relue uniquement si elle n'est pas fournie par record
+	var key *structField
+	fields := structFields(value.Type())
+	for i := range fields {
+		field := &fields[i]
+		fieldValue := value.FieldByIndex(field.Index)
+		if fieldValue.IsZero() && (field.AutoIncrement || field.OmitEmpty) {
+			if key == nil && (field.AutoIncrement || field.PrimaryKey) {
+				key = field
+			}
+			continue
+		}
+		columns = append(columns, field.Column)
+		values = append(values, fieldValue.Interface())
+	}
+
+	returning := t.PrimaryKey
+	if key != nil {
+		returning = key.Column
+	}
+	returnFunction, insertedId := t.insert(ctx, &Record{Fields: columns, Values: values, Struct: record}, returning)
+	if key != nil && insertedId != nil && returnFunction.StatusCode == 200 {
+		if err := assignValue(value.FieldByIndex(key.Index), insertedId); err != nil {
+			returnFunction.ErrorMessage = "Clé générée non recopiée : " + err.Error()
+		}
+	}
+	return returnFunction
+}
+
+// UpdateStruct met à jour l'enregistrement record, pointeur vers une structure taguée,
+// identifié par ses champs pk. Les champs omitempty vides ne sont pas mis à jour
+func (t Table) UpdateStruct(record interface{}) ReturnFunction {
+	return t.UpdateStructContext(context.Background(), record)
+}
+
+// UpdateStructContext version de UpdateStruct interrompue à l'annulation de ctx
+func (t Table) UpdateStructContext(ctx context.Context, record interface{}) ReturnFunction {
+	value, err := structValue(record)
+	if err != nil {
+		return failure(ctx, "Update error", err)
+	}
+	var columns []string
+	var values []interface{}
+	var filter []string
+	var args []interface{}
+	for _, field := range structFields(value.Type()) {
+		fieldValue := value.FieldByIndex(field.Index)
+		if field.PrimaryKey {
+			filter = append(filter, field.Column+" = ?")
+			args = append(args, fieldValue.Interface())
+			continue
+		}
+		if field.AutoIncrement || (field.OmitEmpty && fieldValue.IsZero()) {
+			continue
+		}
+		columns = append(columns, field.Column)
+		values = append(values, fieldValue.Interface())
+	}
+	if len(filter) == 0 {
+		return failure(ctx, "Update error", validation(errors.New("Aucun champ pk dans "+value.Type().Name())))
+	}
+	return t.update(ctx, &Record{Fields: columns, Values: values, Filter: strings.Join(filter, " and "), Args: args, Struct: record})
+}
+
+// structValue retourne la structure pointée par record
+func structValue(record interface{}) (reflect.Value, error) {
+	value := reflect.ValueOf(record)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("Un pointeur vers une structure est attendu : %T", record)
+	}
+	return value.Elem(), nil
+}
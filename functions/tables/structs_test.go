@@ -0,0 +1,41 @@
+package tables
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type updateUser struct {
+	ID    int64  `db:"id,pk,autoincrement"`
+	Email string `db:"email,omitempty"`
+}
+
+type noKey struct {
+	Email string `db:"email"`
+}
+
+func TestUpdateWithoutColumns(t *testing.T) {
+	t.Setenv("LOG", "false")
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("create table users (id integer primary key, email text)"); err != nil {
+		t.Fatal(err)
+	}
+	table := Table{Dialect: "sqlite3", Db: db, TableName: "users", Validator: DefaultValidator{}}
+	tests := []struct {
+		name   string
+		result ReturnFunction
+	}{
+		{"UpdateWhere sans colonne", table.UpdateWhere(nil, nil, "id = ?", 1)},
+		{"UpdateStruct sans valeur", table.UpdateStruct(&updateUser{ID: 1})},
+		{"UpdateStruct sans pk", table.UpdateStruct(&noKey{Email: "a@b.fr"})},
+	}
+	for _, test := range tests {
+		if test.result.StatusCode != 422 || test.result.ErrorCode != "validation" {
+			t.Errorf("%s : %d %s %q, attendu 422 validation", test.name, test.result.StatusCode, test.result.ErrorCode, test.result.ErrorMessage)
+		}
+	}
+}
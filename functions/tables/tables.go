@@ -1,11 +1,17 @@
 package tables
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/jsavajols/goframework/functions/database"
+	"github.com/jsavajols/goframework/functions/dialects"
 	"github.com/jsavajols/goframework/functions/fstrings"
 
 	con "github.com/jsavajols/goframework/const"
@@ -17,6 +23,7 @@ import (
 )
 
 // Validator interface pour la validation
+// Les hooks qui reçoivent l'enregistrement et le résultat (BeforeInsertHook...) sont optionnels
 type Validator interface {
 	ValidateRecord() error
 	BeforeInsert() error
@@ -32,15 +39,36 @@ type DefaultValidator struct{}
 
 // Structure de base Table
 type Table struct {
-	Dialect          string
-	Database         string
-	TableName        string
-	Source           string
-	Struct           interface{}
-	Db               *sql.DB
+	Dialect  string
+	Database string
+	// Connection nom d'une connexion enregistrée avec database.Register,
+	// prioritaire sur Dialect et Database
+	Connection string
+	TableName  string
+	// PrimaryKey colonne clé de la table, relue par Insert avec RETURNING
+	// pour postgres et sqlite afin de renseigner LastInsertId
+	PrimaryKey string
+	Source     string
+	// Struct type des enregistrements retournés par Get, par exemple Struct: User{}
+	// Si elle est définie, Rows contient un []User au lieu de []map[string]interface{}
+	// Les règles de ses tags validate sont vérifiées par Insert, Update, Upsert et BulkInsert (voir ValidateValues)
+	Struct interface{}
+	Db     *sql.DB
+	// Cluster connexion en écriture et réplicas en lecture, prioritaire sur Db et Connection
+	// Les réplicas d'une connexion nommée sont ceux de sa Config
+	Cluster          *database.Cluster
+	Tx               *Tx
 	Validator        Validator
 	DefaultValidator DefaultValidator
 	ReadOnly         bool
+	// Timeout durée maximale des requêtes de la table, sans limite si 0
+	Timeout time.Duration
+	// CountTotal calcule le nombre total de lignes correspondant au filtre dans la pagination
+	// retournée par Get, GetWhere et GetQuery (voir Pagination)
+	CountTotal bool
+	// CheckSchema vérifie les champs et valeurs de Insert, Update, Upsert et BulkInsert
+	// avec la description de la table (voir Schema) avant l'envoi de la requête
+	CheckSchema bool
 }
 
 type ReturnFunction struct {
@@ -49,10 +77,21 @@ type ReturnFunction struct {
 	ErrorMessage  string `json:"errorMessage"`
 	GetRecords    int    `json:"getRecords"`
 	Rows          interface{}
-	InsertRecords int64 `json:"insertRecords"`
-	UpdateRecords int64 `json:"updateRecords"`
-	DeleteRecords int64 `json:"deleteRecords"`
-	LastInsertId  int64 `json:"lastInsertId"`
+	Pagination    *Pagination `json:"pagination,omitempty"`
+	InsertRecords int64       `json:"insertRecords"`
+	UpdateRecords int64       `json:"updateRecords"`
+	DeleteRecords int64       `json:"deleteRecords"`
+	LastInsertId  int64       `json:"lastInsertId"`
+	RowErrors     []RowError  `json:"rowErrors,omitempty"`
+	// FieldErrors erreurs par colonne des règles du tag validate de Struct (voir ValidateValues)
+	FieldErrors []FieldError `json:"fieldErrors,omitempty"`
+	// ErrorCode type de l'erreur (not_found, conflict, validation...), voir Error
+	ErrorCode string `json:"errorCode,omitempty"`
+	// Err erreur typée de l'opération, à tester avec errors.Is et errors.As
+	Err error `json:"-"`
+	// HookError erreur d'un hook After... hors transaction : l'écriture a eu lieu,
+	// StatusCode et les compteurs sont ceux de l'opération
+	HookError string `json:"hookError,omitempty"`
 }
 
 func (dv DefaultValidator) ValidateRecord() error {
@@ -75,22 +114,43 @@ func (dv DefaultValidator) BeforeInsert() error {
 
 // ExecSql exécute une requête SQL et retourne le résultat ou une erreur
 func ExecSql(dbName, dialect, sql string) (sql.Result, error) {
-	db, _, _ := database.ConnectDatabase(dbName, dialect)
+	return ExecSqlContext(context.Background(), dbName, dialect, sql)
+}
+
+// ExecSqlContext exécute une requête SQL interrompue à l'annulation de ctx
+func ExecSqlContext(ctx context.Context, dbName, dialect, query string) (sql.Result, error) {
+	db, _, _ := database.GetDatabase(dbName, dialect)
 	if db == nil {
 		return nil, fmt.Errorf("Erreur de connexion à la base de données")
 	}
-	defer db.Close()
-	logs.Logs("Exécution de la requête SQL:", sql)
-	result, err := db.Exec(sql)
+	logs.Logs("Exécution de la requête SQL:", query)
+	result, err := db.ExecContext(ctx, query)
 	if err != nil {
 		log.Error("Erreur lors de l'exécution de la requête SQL:", err)
 		return nil, err
 	}
+	database.MarkWrite(ctx)
 	return result, nil
 }
 
 // Insert méthode pour Table
 func (t *Table) Insert(fields string, values []interface{}) ReturnFunction {
+	return t.InsertContext(context.Background(), fields, values)
+}
+
+// InsertContext insère l'enregistrement, la requête est interrompue à l'annulation de ctx
+func (t *Table) InsertContext(ctx context.Context, fields string, values []interface{}) ReturnFunction {
+	returnFunction, _ := t.insert(ctx, &Record{Fields: splitFields(fields), Values: values}, t.PrimaryKey)
+	return returnFunction
+}
+
+// insert exécute l'insertion de record et retourne la clé générée
+// Si returning est renseigné, la colonne est relue avec RETURNING quand le dialecte le permet
+// (postgres ne supporte pas LastInsertId), sinon LastInsertId est utilisé
+func (t *Table) insert(ctx context.Context, record *Record, returning string) (ReturnFunction, interface{}) {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+	var insertedId interface{}
 	errorMessage := ""
 	var statusCode int32
 	var message string
@@ -99,75 +159,75 @@ func (t *Table) Insert(fields string, values []interface{}) ReturnFunction {
 	logs.Logs("Début de l'insertion dans", t.TableName)
 	// Si la table est en lecture seule, on renvoie une erreur
 	if t.ReadOnly {
-		return ReturnFunction{
-			StatusCode:    500,
-			Message:       "Insert error",
-			ErrorMessage:  "Table is read only",
-			InsertRecords: 0,
-			LastInsertId:  0,
-		}
+		return failure(ctx, "Insert error", ErrReadOnly), nil
 	}
 
-	// Appel de BeforeInsert et gestion de l'erreur
-	err := t.Validator.BeforeInsert()
+	// Transmet la transaction en cours au Validator, appel de BeforeInsert qui peut modifier
+	// l'enregistrement puis contrôle des valeurs et du schéma
+	err := t.bindTx()
+	if err == nil {
+		if err = t.beforeInsert(ctx, record); err == nil {
+			err = t.checkValues(ctx, record.Fields, record.Values, true)
+		}
+		if err != nil {
+			err = validation(err)
+		}
+	}
 	if err != nil {
 		logs.Logs("Échec lors de la préparation avant insertion:", err)
-		return ReturnFunction{
-			StatusCode:    500,
-			Message:       "Insert error",
-			ErrorMessage:  err.Error(),
-			InsertRecords: 0,
-			LastInsertId:  0,
-		}
-
+		t.abort(err)
+		return failure(ctx, "Insert error", err), nil
 	}
 
 	// Ici, insérez la logique d'insertion réelle si BeforeInsert réussit
+	fields := "(" + strings.Join(record.Fields, ", ") + ")"
+	values := record.Values
 
 	var sqlResult sql.Result
-	db, dialect, _ := t.Open()
+	db, dialect, err := t.conn()
+	if err != nil {
+		return failure(ctx, "Insert error", err), nil
+	}
 	t.Dialect = dialect
-	nbPoints := ""
-	if t.Dialect == "postgres" {
-		nbPoints = "("
-		for i := 0; i < len(values); i++ {
-			if i == len(values)-1 {
-				nbPoints = nbPoints + "$" + fstrings.ToString(i+1) + ") "
-			} else {
-				nbPoints = nbPoints + "$" + fstrings.ToString(i+1) + ", "
-			}
+	d := t.dialect()
+	nbPoints := sqlFunctions.Placeholders(0, len(values), t.Dialect)
+	if returning != "" && d.SupportsReturning() {
+		query := d.Insert(t.TableName, fields, nbPoints, returning)
+		logs.Logs(query, values)
+		err = db.QueryRowContext(ctx, query, values...).Scan(&insertedId)
+		if b, ok := insertedId.([]byte); ok {
+			insertedId = string(b)
 		}
 	} else {
-		nbPoints = "("
-		for i := 0; i < len(values); i++ {
-			if i == len(values)-1 {
-				nbPoints = nbPoints + "?) "
-			} else {
-				nbPoints = nbPoints + "?, "
-			}
-		}
+		query := d.Insert(t.TableName, fields, nbPoints, "")
+		logs.Logs(query, values)
+		sqlResult, err = db.ExecContext(ctx, query, values...)
 	}
-	logs.Logs("INSERT INTO "+t.TableName+" "+fields+"  VALUES "+nbPoints, values)
-	sqlResult, err = db.Exec("INSERT INTO "+t.TableName+" "+fields+"  VALUES "+nbPoints, values...)
 	if err != nil {
 		errorMessage = err.Error()
 		log.Error(errorMessage)
+		t.abort(err)
 	}
 
 	if errorMessage == "" {
 		statusCode = 200
 		message = "Insert success"
-		rowsAffected, _ = sqlResult.RowsAffected()
-		lastInsertId, _ = sqlResult.LastInsertId()
+		database.MarkWrite(ctx)
+		if sqlResult != nil {
+			rowsAffected, _ = sqlResult.RowsAffected()
+			lastInsertId, _ = sqlResult.LastInsertId()
+			insertedId = lastInsertId
+		} else {
+			rowsAffected = 1
+			lastInsertId = int64(fstrings.ToInt(insertedId))
+		}
 	} else {
-		statusCode = 500
 		message = "Insert error"
 		rowsAffected = 0
 		lastInsertId = 0
+		insertedId = nil
 	}
 
-	t.Validator.AfterInsert()
-	t.Close(db)
 	returnFunction := ReturnFunction{
 		StatusCode:    statusCode,
 		Message:       message,
@@ -175,134 +235,239 @@ func (t *Table) Insert(fields string, values []interface{}) ReturnFunction {
 		InsertRecords: rowsAffected,
 		LastInsertId:  lastInsertId,
 	}
+	// L'échec de AfterInsert annule la transaction en cours, sinon il est retourné dans HookError
+	if err == nil {
+		if hookErr := t.afterInsert(ctx, record, returnFunction); hookErr != nil && t.Tx != nil {
+			err = hookErr
+			t.abort(err)
+			returnFunction.Message = "Insert error"
+			returnFunction.InsertRecords = 0
+			returnFunction.LastInsertId = 0
+			insertedId = nil
+		} else if hookErr != nil {
+			returnFunction.setHookError(hookErr)
+		}
+	}
+	if err != nil {
+		returnFunction.setError(ctx, err)
+	}
 
 	logs.Logs("Insertion réussie dans", t.TableName)
 
-	return returnFunction
+	return returnFunction, insertedId
 }
 
+// Open retourne la connexion en écriture de la table : Cluster.Writer ou Db si elle est renseignée,
+// sinon la connexion nommée Connection, sinon le pool partagé pour le couple (Dialect, Database)
 func (t Table) Open() (*sql.DB, string, error) {
 	logs.Logs("database " + t.Database + " " + t.TableName + " open.")
-	return database.ConnectDatabase(t.Database, t.Dialect)
+	if t.Cluster != nil {
+		return t.Cluster.Writer, t.dialectName(), nil
+	}
+	if t.Db != nil {
+		return t.Db, t.dialectName(), nil
+	}
+	if t.Connection != "" {
+		return database.Connection(t.Connection)
+	}
+	return database.GetDatabase(t.Database, t.Dialect)
 }
 
+// OpenReader retourne la connexion de lecture de la table : un réplica de Cluster,
+// de la connexion nommée Connection ou de DB_REPLICAS (voir database.GetReader),
+// Db si elle est renseignée
+// La connexion principale est utilisée si ctx le demande (voir database.WithReadYourWrites)
+func (t Table) OpenReader(ctx context.Context) (*sql.DB, string, error) {
+	if database.UsePrimary(ctx) {
+		return t.Open()
+	}
+	if t.Cluster != nil {
+		return t.Cluster.Reader(), t.dialectName(), nil
+	}
+	if t.Db != nil {
+		return t.Open()
+	}
+	if t.Connection != "" {
+		return database.Reader(t.Connection)
+	}
+	return database.GetReader(t.Database, t.Dialect)
+}
+
+// dialectName retourne le dialecte de la table, DB_DIALECT s'il n'est pas renseigné
+func (t Table) dialectName() string {
+	if t.Dialect == "" {
+		return os.Getenv("DB_DIALECT")
+	}
+	return t.Dialect
+}
+
+// Close libère la connexion de la table
+// Les connexions sont partagées entre les appels : elles ne sont pas fermées ici
 func (t Table) Close(db *sql.DB) {
 	logs.Logs(t.TableName + " close.")
-	defer db.Close()
 }
 
+// Get retourne les enregistrements de la table.
+// search est inséré tel quel dans la clause where : pour des valeurs venant de l'extérieur,
+// utiliser GetWhere.
 func (t Table) Get(fields string, search string, sort string, start int, limit int) ReturnFunction {
+	return t.GetWhereContext(context.Background(), fields, search, nil, sort, start, limit)
+}
+
+// GetContext retourne les enregistrements de la table, la requête est interrompue à l'annulation de ctx
+func (t Table) GetContext(ctx context.Context, fields string, search string, sort string, start int, limit int) ReturnFunction {
+	return t.GetWhereContext(ctx, fields, search, nil, sort, start, limit)
+}
+
+// GetWhere retourne les enregistrements correspondant au filtre.
+// Les valeurs sont passées dans args et référencées par des ? dans filter
+// (convertis en $1, $2... pour postgres), par exemple :
+// GetWhere("id, name", "status = ? and id > ?", []interface{}{"open", 10}, "name", 0, 0)
+func (t Table) GetWhere(fields string, filter string, args []interface{}, sort string, start int, limit int) ReturnFunction {
+	return t.GetWhereContext(context.Background(), fields, filter, args, sort, start, limit)
+}
+
+// GetWhereContext version de GetWhere interrompue à l'annulation de ctx
+func (t Table) GetWhereContext(ctx context.Context, fields string, filter string, args []interface{}, sort string, start int, limit int) ReturnFunction {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
 	message := ""
 	errorMessage := ""
 	getRecords := 0
 	limits := ""
 	var statusCode int32
 
-	db, dialect, _ := t.Open()
+	db, dialect, err := t.readConn(ctx)
+	if err == nil {
+		err = t.bindTx()
+	}
+	if err != nil {
+		returnFunction := failure(ctx, "Get error", err)
+		returnFunction.Rows = make([]map[string]interface{}, 0)
+		return returnFunction
+	}
 	t.Dialect = dialect
 
 	if fields == "" {
 		fields = "*"
 	}
-	if search == "" {
-		search = "true"
+	if filter == "" {
+		filter = "1 = 1"
 	}
 	if sort != "" {
 		sort = " order by " + sort
 	}
-	// Gère start et limit
-	if start != 0 || limit != 0 {
-		if t.Dialect == "postgres" {
-			limits = " offset " + fmt.Sprint(start) + " limit " + fmt.Sprint(limit)
-		} else {
-			limits = " limit " + fmt.Sprint(start) + ", " + fmt.Sprint(limit)
-		}
-	}
 	// Limite au nombre de lignes maximum defini dans const/const.go
 	if limit > con.ROWS_LIMIT {
 		errorMessage = "Limit too high"
 		limit = con.ROWS_LIMIT
 	}
+	// Gère start et limit
+	limits = t.dialect().Paginate(t.pageFetch(limit), start, sort != "")
 
-	sql := t.buildQuery(fields, search, sort, limits)
-	logs.Logs(sql)
-	stmt, err := db.Prepare(sql)
-	var tableData []map[string]interface{}
+	query := t.buildQuery(fields, filter, sort, limits)
+	var tableData interface{}
+	if query == "" {
+		err = errRejected
+	} else {
+		query = sqlFunctions.Rebind(query, t.Dialect)
+		logs.Logs(query, args)
+		tableData, getRecords, err = t.queryRows(ctx, db, query, args)
+	}
+	var pagination *Pagination
+	if err == nil {
+		pagination, tableData, getRecords, err = t.paginate(ctx, db, tableData, getRecords, start, limit, func() (string, []interface{}) {
+			return t.buildCountQuery(filter), args
+		})
+	}
 	if err != nil {
-		statusCode = 500
 		message = "Get error"
-		errorMessage = err.Error()
 		// Retourne un tableau vide
 		tableData = make([]map[string]interface{}, 0)
 		getRecords = 0
-
 	} else {
 		statusCode = 200
 		message = "Get success"
-		rows, _ := stmt.Query()
-		tableData = t.fetchData(rows)
-		getRecords = len(tableData)
-		defer rows.Close()
-		defer stmt.Close()
 	}
 
-	t.Close(db)
 	returnFunction := ReturnFunction{
 		StatusCode:   statusCode,
 		Message:      message,
 		ErrorMessage: errorMessage,
 		GetRecords:   getRecords,
 		Rows:         tableData,
+		Pagination:   pagination,
+	}
+	if err != nil {
+		returnFunction.setError(ctx, err)
 	}
 	return returnFunction
 }
 
+// dialect retourne le dialecte de la table, mysql par défaut
+func (t Table) dialect() dialects.Dialect {
+	return dialects.Must(t.Dialect)
+}
+
+// queryRows exécute la requête et lit les lignes retournées avec fetchRows
+func (t Table) queryRows(ctx context.Context, db executor, query string, args []interface{}) (interface{}, int, error) {
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer stmt.Close()
+	rows, err := stmt.QueryContext(ctx, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	return t.fetchRows(rows)
+}
+
 func (t Table) buildQuery(fields string, search string, sort string, limits string) string {
 	toReturn := ""
-	if search != "-" {
-		search = " where " + search
-	} else {
-		search = ""
-	}
+	search = whereClause(search)
 	if t.Source != "" {
 		toReturn = t.Source + search + sort + limits
 	} else {
 		toReturn = "select " + fields + " from " + t.TableName + search + sort + limits
 	}
-	if t.Dialect != "mysql" {
-		toReturn = strings.ReplaceAll(toReturn, "RAND", "RANDOM")
-		toReturn = strings.ReplaceAll(toReturn, "ucase", "upper")
-	}
-	if t.Dialect == "postgres" {
-		toReturn = strings.ReplaceAll(toReturn, `""`, `**++--`)
-		toReturn = strings.ReplaceAll(toReturn, `"`, `'`)
-		toReturn = strings.ReplaceAll(toReturn, `**++--`, `"`)
-		// ajoute des " pour les noms de colonnes
-		fieldsList := strings.Split(fields, ",")
-		for i, field := range fieldsList {
-			fieldsList[i] = "\"" + strings.TrimSpace(field) + "\""
-		}
-		fields = strings.Join(fieldsList, ",")
-		// Si la source est définie, on recherche les noms de colonnes dans la source pour y ajouter des " si besoin
-		if t.Source != "" {
-			fieldsList := strings.Split(t.Source, "as")
-			for i, field := range fieldsList {
-				startField := strings.Index(field, " ")
-				endField := strings.LastIndex(field, " ")
-				if endField == -1 {
-					endField = strings.LastIndex(field, ",")
-				}
-				fieldsList[i] = "\"" + field[startField:endField] + "\""
-			}
-			fields = strings.Join(fieldsList, ",")
-		}
-	}
+	toReturn = t.dialect().Translate(toReturn)
 	if sqlFunctions.CheckForSQLInjection(toReturn) {
 		return ""
 	}
 	return toReturn
 }
 
+// whereClause retourne la clause where du filtre, vide si le filtre est vide ou vaut "-"
+func whereClause(filter string) string {
+	if filter == "-" || strings.TrimSpace(filter) == "" {
+		return ""
+	}
+	return " where " + filter
+}
+
+// fetchRows lit les lignes dans un slice de Struct si elle est définie, sinon dans des maps
+// et retourne le nombre d'enregistrements lus
+func (t Table) fetchRows(rows *sql.Rows) (interface{}, int, error) {
+	if t.Struct == nil {
+		tableData := t.fetchData(rows)
+		if err := rows.Err(); err != nil {
+			return nil, 0, err
+		}
+		return tableData, len(tableData), nil
+	}
+	typ, err := structType(t.Struct)
+	if err != nil {
+		return nil, 0, err
+	}
+	tableData, err := t.fetchStructs(rows, typ)
+	if err != nil {
+		return nil, 0, err
+	}
+	return tableData, reflect.ValueOf(tableData).Len(), nil
+}
+
 func (t Table) fetchData(rows *sql.Rows) []map[string]interface{} {
 	columns, _ := rows.Columns()
 
@@ -358,7 +523,45 @@ func (dv DefaultValidator) AfterUpdate() bool {
 	return true
 }
 
+// Update met à jour les enregistrements correspondant au filtre.
+// Les valeurs sont passées en paramètres de la requête, un nil Go est enregistré à null.
+// types n'est plus nécessaire et peut être nil : il est conservé pour compatibilité,
+// la chaine "null" y reste traitée comme une valeur null.
+// filter est inséré tel quel dans la clause where : pour des valeurs venant de l'extérieur,
+// utiliser UpdateWhere.
 func (t Table) Update(fields []string, values []interface{}, types []interface{}, filter string) ReturnFunction {
+	return t.UpdateContext(context.Background(), fields, values, types, filter)
+}
+
+// UpdateContext version de Update interrompue à l'annulation de ctx
+func (t Table) UpdateContext(ctx context.Context, fields []string, values []interface{}, types []interface{}, filter string) ReturnFunction {
+	if types != nil {
+		values = append([]interface{}{}, values...)
+		for i, value := range values {
+			if value == "null" {
+				values[i] = nil
+			}
+		}
+	}
+	return t.UpdateWhereContext(ctx, fields, values, filter)
+}
+
+// UpdateWhere met à jour les enregistrements correspondant au filtre.
+// Les valeurs des champs et celles du filtre sont passées en paramètres de la requête,
+// les valeurs du filtre sont référencées par des ? dans filter (convertis en $n pour postgres).
+func (t Table) UpdateWhere(fields []string, values []interface{}, filter string, args ...interface{}) ReturnFunction {
+	return t.UpdateWhereContext(context.Background(), fields, values, filter, args...)
+}
+
+// UpdateWhereContext version de UpdateWhere interrompue à l'annulation de ctx
+func (t Table) UpdateWhereContext(ctx context.Context, fields []string, values []interface{}, filter string, args ...interface{}) ReturnFunction {
+	return t.update(ctx, &Record{Fields: fields, Values: values, Filter: filter, Args: args})
+}
+
+// update exécute la mise à jour des colonnes de record sur les lignes de son filtre
+func (t Table) update(ctx context.Context, record *Record) ReturnFunction {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
 	errorMessage := ""
 	var statusCode int32
 	var message string
@@ -367,68 +570,66 @@ func (t Table) Update(fields []string, values []interface{}, types []interface{}
 
 	// Si la table est en lecture seule, on renvoie une erreur
 	if t.ReadOnly {
-		return ReturnFunction{
-			StatusCode:    500,
-			Message:       "Update error",
-			ErrorMessage:  "Table is read only",
-			InsertRecords: 0,
-			LastInsertId:  0,
-		}
+		return failure(ctx, "Update error", ErrReadOnly)
 	}
 
-	// Appel de BeforeUpdate et gestion de l'erreur
-	err := t.Validator.BeforeUpdate(values)
+	// Transmet la transaction en cours au Validator, appel de BeforeUpdate qui peut modifier
+	// l'enregistrement puis contrôle des valeurs et du schéma
+	err := t.bindTx()
+	if err == nil {
+		if err = t.beforeUpdate(ctx, record); err == nil {
+			err = t.checkValues(ctx, record.Fields, record.Values, false)
+		}
+		if err != nil {
+			err = validation(err)
+		}
+	}
 	if err != nil {
 		logs.Logs("Échec lors de la préparation avant mise à jour:", err)
-		return ReturnFunction{
-			StatusCode:    500,
-			Message:       "Update error",
-			ErrorMessage:  err.Error(),
-			InsertRecords: 0,
-			LastInsertId:  0,
-		}
+		t.abort(err)
+		return failure(ctx, "Update error", err)
 	}
 
 	// Ici, insérez la logique de mise à jour réelle si BeforeUpdate réussit
-	db, dialect, _ := t.Open()
+	fields, values, filter := record.Fields, record.Values, record.Filter
+	db, dialect, err := t.conn()
+	if err != nil {
+		return failure(ctx, "Update error", err)
+	}
 	t.Dialect = dialect
-	toUpdate := ""
-	quote := con.QUOTE
-	if t.Dialect == "postgres" {
-		quote = "'"
-	}
-	for i := 0; i < len(fields); i++ {
-		if i == len(values)-1 {
-			if types[i] == "string" || types[i] == "date" || types[i] == "datetime" {
-				toUpdate = toUpdate + fields[i] + " = " + quote + fmt.Sprintf("%v", values[i]) + quote
-			} else {
-				toUpdate = toUpdate + fields[i] + " = " + fmt.Sprintf("%v", values[i])
-			}
-		} else {
-			if types[i] == "string" || types[i] == "date" || types[i] == "datetime" {
-				toUpdate = toUpdate + fields[i] + " = " + quote + fmt.Sprintf("%v", values[i]) + quote + ", "
-			} else {
-				toUpdate = toUpdate + fields[i] + " = " + fmt.Sprintf("%v", values[i]) + ", "
-			}
-		}
+	if len(fields) != len(values) {
+		return failure(ctx, "Update error", validation(errors.New("Le nombre de champs ne correspond pas au nombre de valeurs")))
+	}
+	if len(fields) == 0 {
+		return failure(ctx, "Update error", validation(errors.New("Aucune colonne à mettre à jour")))
+	}
+	// Les champs sont mis à jour avec des ?, convertis pour le dialecte par Rebind
+	toUpdate := make([]string, len(fields))
+	for i, field := range fields {
+		toUpdate[i] = field + " = ?"
 	}
-
-	// Corrige les valeurs null pour enlever les quotes
-	toUpdate = strings.ReplaceAll(toUpdate, quote+"null"+quote, "null")
 
 	if filter != "" {
 		filter = " where " + filter
 	} else {
-		filter = " where true"
+		filter = " where 1 = 1"
 	}
 
-	logs.Logs("UPDATE " + t.TableName + " set " + toUpdate + filter)
+	query := sqlFunctions.Rebind("UPDATE "+t.TableName+" set "+strings.Join(toUpdate, ", ")+filter, t.Dialect)
+	args := append(append([]interface{}{}, values...), record.Args...)
+	logs.Logs(query, args)
 
-	sqlResult, err := db.Exec("UPDATE " + t.TableName + " set " + toUpdate + filter)
+	var sqlResult sql.Result
+	if sqlFunctions.CheckForSQLInjection(filter) {
+		err = errRejected
+	} else {
+		sqlResult, err = db.ExecContext(ctx, query, args...)
+	}
 	if err != nil {
 		errorMessage = err.Error()
 		rowsAffected = 0
 		log.Error(errorMessage)
+		t.abort(err)
 	} else {
 		rowsAffected, _ = sqlResult.RowsAffected()
 	}
@@ -436,14 +637,12 @@ func (t Table) Update(fields []string, values []interface{}, types []interface{}
 	if errorMessage == "" {
 		statusCode = 200
 		message = "Update success"
+		database.MarkWrite(ctx)
 	} else {
-		statusCode = 500
 		rowsAffected = 0
 		message = "Update error"
 	}
 
-	t.Validator.AfterUpdate()
-	t.Close(db)
 	returnFunction := ReturnFunction{
 		StatusCode:    statusCode,
 		Message:       message,
@@ -452,6 +651,20 @@ func (t Table) Update(fields []string, values []interface{}, types []interface{}
 		UpdateRecords: rowsAffected,
 		LastInsertId:  0,
 	}
+	// L'échec de AfterUpdate annule la transaction en cours, sinon il est retourné dans HookError
+	if err == nil {
+		if hookErr := t.afterUpdate(ctx, record, returnFunction); hookErr != nil && t.Tx != nil {
+			err = hookErr
+			t.abort(err)
+			returnFunction.Message = "Update error"
+			returnFunction.UpdateRecords = 0
+		} else if hookErr != nil {
+			returnFunction.setHookError(hookErr)
+		}
+	}
+	if err != nil {
+		returnFunction.setError(ctx, err)
+	}
 
 	logs.Logs("Mise à jour réussie dans", t.TableName)
 
@@ -463,66 +676,106 @@ func (dv DefaultValidator) BeforeDelete() error {
 	return nil
 }
 
+// Delete supprime les enregistrements correspondant au filtre.
+// search est inséré tel quel dans la clause where : pour des valeurs venant de l'extérieur,
+// utiliser DeleteWhere.
 func (t Table) Delete(search string) ReturnFunction {
+	return t.DeleteWhereContext(context.Background(), search)
+}
+
+// DeleteContext version de Delete interrompue à l'annulation de ctx
+func (t Table) DeleteContext(ctx context.Context, search string) ReturnFunction {
+	return t.DeleteWhereContext(ctx, search)
+}
+
+// DeleteWhere supprime les enregistrements correspondant au filtre.
+// Les valeurs du filtre sont passées dans args et référencées par des ? dans filter
+// (convertis en $1, $2... pour postgres).
+func (t Table) DeleteWhere(filter string, args ...interface{}) ReturnFunction {
+	return t.DeleteWhereContext(context.Background(), filter, args...)
+}
+
+// DeleteWhereContext version de DeleteWhere interrompue à l'annulation de ctx
+func (t Table) DeleteWhereContext(ctx context.Context, filter string, args ...interface{}) ReturnFunction {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
 	errorMessage := ""
 	var statusCode int32
 	// Si la table est en lecture seule, on renvoie une erreur
 	if t.ReadOnly {
-		return ReturnFunction{
-			StatusCode:    500,
-			Message:       "Delete error",
-			ErrorMessage:  "Table is read only",
-			InsertRecords: 0,
-			LastInsertId:  0,
-		}
+		return failure(ctx, "Delete error", ErrReadOnly)
 	}
 
-	if search == "" {
-		search = "true"
+	if filter == "" {
+		filter = "1 = 1"
+	}
+	db, dialect, err := t.conn()
+	if err != nil {
+		return failure(ctx, "Delete error", err)
 	}
-	db, dialect, _ := t.Open()
 	t.Dialect = dialect
-	err := t.Validator.BeforeDelete()
+	// Transmet la transaction en cours au Validator puis appel de BeforeDelete qui peut modifier le filtre
+	record := &Record{Filter: filter, Args: args}
+	err = t.bindTx()
+	if err == nil {
+		if err = t.beforeDelete(ctx, record); err != nil {
+			err = validation(err)
+		}
+	}
 	if err != nil {
 		logs.Logs("Échec lors de la préparation avant suppression:", err)
-		return ReturnFunction{
-			StatusCode:    500,
-			Message:       "Delete error",
-			ErrorMessage:  err.Error(),
-			InsertRecords: 0,
-			LastInsertId:  0,
-		}
+		t.abort(err)
+		return failure(ctx, "Delete error", err)
 	}
+	filter, args = record.Filter, record.Args
 
-	sql := "DELETE from " + t.TableName + " where " + search
-	sqlResult, err := db.Exec(sql)
+	query := sqlFunctions.Rebind("DELETE from "+t.TableName+" where "+filter, t.Dialect)
+	logs.Logs(query, args)
+	var sqlResult sql.Result
+	if sqlFunctions.CheckForSQLInjection(filter) {
+		err = errRejected
+	} else {
+		sqlResult, err = db.ExecContext(ctx, query, args...)
+	}
 	var rowsAffected int64
 	if err != nil {
-		errorMessage = err.Error()
+		t.abort(err)
 	} else {
 		rowsAffected, _ = sqlResult.RowsAffected()
 	}
-	t.Validator.AfterDelete()
-	t.Close(db)
 	var message string
-	if errorMessage == "" {
+	if err == nil {
 		statusCode = 200
 		message = "Delete success"
+		database.MarkWrite(ctx)
 	} else {
-		statusCode = 500
 		message = "Delete error"
 	}
-	// Si rowsAffected = 0, il y a eu une erreur
-	if rowsAffected == 0 {
-		statusCode = 500
-		message = "0 rows affected"
-	}
 	returnFunction := ReturnFunction{
 		StatusCode:    statusCode,
 		Message:       message,
 		ErrorMessage:  errorMessage,
 		DeleteRecords: rowsAffected,
 	}
+	// Aucune ligne supprimée : rien ne correspond au filtre
+	if err == nil && rowsAffected == 0 {
+		err = ErrNotFound
+		returnFunction.Message = "0 rows affected"
+	}
+	// L'échec de AfterDelete annule la transaction en cours, sinon il est retourné dans HookError
+	if err == nil {
+		if hookErr := t.afterDelete(ctx, record, returnFunction); hookErr != nil && t.Tx != nil {
+			err = hookErr
+			t.abort(err)
+			returnFunction.Message = "Delete error"
+			returnFunction.DeleteRecords = 0
+		} else if hookErr != nil {
+			returnFunction.setHookError(hookErr)
+		}
+	}
+	if err != nil {
+		returnFunction.setError(ctx, err)
+	}
 	return returnFunction
 }
 
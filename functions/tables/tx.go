@@ -0,0 +1,179 @@
+package tables
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jsavajols/goframework/functions/database"
+	logs "github.com/jsavajols/goframework/functions/logs"
+)
+
+// executor regroupe les méthodes communes à *sql.DB et *sql.Tx
+// utilisées par les opérations de Table
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// Tx transaction partagée par plusieurs opérations sur des Table
+// Les méthodes de *sql.Tx (Exec, Query...) restent accessibles, notamment depuis les Validator
+type Tx struct {
+	*sql.Tx
+	Dialect string
+	err     error
+}
+
+// TxValidator est implémenté par les Validator qui ont besoin de la transaction en cours :
+// SetTx est appelé avant les hooks quand l'opération s'exécute dans une transaction
+type TxValidator interface {
+	SetTx(tx *Tx)
+}
+
+// Begin démarre une transaction sur le pool partagé du couple (dialecte, base de données)
+func Begin(dbName, dialect string) (*Tx, error) {
+	return BeginContext(context.Background(), dbName, dialect)
+}
+
+// BeginContext démarre une transaction annulée par un rollback à l'annulation de ctx
+func BeginContext(ctx context.Context, dbName, dialect string) (*Tx, error) {
+	db, dialect, err := database.GetDatabase(dbName, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return begin(ctx, db, dialect)
+}
+
+// Begin démarre une transaction sur la connexion de la table
+func (t Table) Begin() (*Tx, error) {
+	return t.BeginContext(context.Background())
+}
+
+// BeginContext démarre une transaction sur la connexion de la table,
+// annulée par un rollback à l'annulation de ctx
+func (t Table) BeginContext(ctx context.Context) (*Tx, error) {
+	db, dialect, err := t.Open()
+	if err != nil {
+		return nil, err
+	}
+	return begin(ctx, db, dialect)
+}
+
+func begin(ctx context.Context, db *sql.DB, dialect string) (*Tx, error) {
+	sqlTx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	logs.Logs("Début de transaction")
+	return &Tx{Tx: sqlTx, Dialect: dialect}, nil
+}
+
+// Abort marque la transaction pour annulation : Commit fera un rollback et retournera err
+func (tx *Tx) Abort(err error) {
+	if tx.err == nil {
+		tx.err = err
+	}
+}
+
+// Err retourne l'erreur qui a provoqué l'annulation de la transaction
+func (tx *Tx) Err() error {
+	return tx.err
+}
+
+// Commit valide la transaction, ou l'annule si elle a été marquée par Abort
+func (tx *Tx) Commit() error {
+	if tx.err != nil {
+		logs.Logs("Annulation de la transaction:", tx.err)
+		tx.Tx.Rollback()
+		return tx.err
+	}
+	logs.Logs("Validation de la transaction")
+	return tx.Tx.Commit()
+}
+
+// Rollback annule la transaction
+func (tx *Tx) Rollback() error {
+	logs.Logs("Annulation de la transaction")
+	return tx.Tx.Rollback()
+}
+
+// WithTx exécute fn dans une transaction sur le pool partagé du couple (dialecte, base de données)
+// La transaction est validée si fn ne retourne pas d'erreur et qu'aucun hook ne l'a annulée
+func WithTx(dbName, dialect string, fn func(tx *Tx) error) error {
+	tx, err := Begin(dbName, dialect)
+	if err != nil {
+		return err
+	}
+	return runTx(tx, fn)
+}
+
+// WithTx exécute fn dans une transaction sur la connexion de la table
+func (t Table) WithTx(fn func(tx *Tx) error) error {
+	tx, err := t.Begin()
+	if err != nil {
+		return err
+	}
+	return runTx(tx, fn)
+}
+
+func runTx(tx *Tx, fn func(tx *Tx) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// InTx retourne une copie de la table dont les opérations s'exécutent dans la transaction tx
+func (t Table) InTx(tx *Tx) Table {
+	t.Tx = tx
+	return t
+}
+
+// conn retourne l'exécuteur des requêtes de la table : la transaction si elle est définie,
+// sinon la connexion retournée par Open
+func (t Table) conn() (executor, string, error) {
+	if t.Tx != nil {
+		return t.Tx, t.Tx.Dialect, nil
+	}
+	return t.Open()
+}
+
+// readConn retourne l'exécuteur des lectures de la table : la transaction si elle est définie,
+// sinon la connexion retournée par OpenReader
+func (t Table) readConn(ctx context.Context) (executor, string, error) {
+	if t.Tx != nil {
+		return t.Tx, t.Tx.Dialect, nil
+	}
+	return t.OpenReader(ctx)
+}
+
+// bindTx transmet la transaction en cours au Validator avant l'appel des hooks
+// et retourne une erreur si elle a déjà été annulée
+func (t Table) bindTx() error {
+	if t.Tx == nil {
+		return nil
+	}
+	if t.Tx.err != nil {
+		return fmt.Errorf("Transaction annulée : %w", t.Tx.err)
+	}
+	if v, ok := t.Validator.(TxValidator); ok {
+		v.SetTx(t.Tx)
+	}
+	return nil
+}
+
+// abort annule la transaction en cours suite à l'échec d'un hook ou d'une requête
+func (t Table) abort(err error) {
+	if t.Tx != nil && err != nil {
+		t.Tx.Abort(err)
+	}
+}
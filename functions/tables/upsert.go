@@ -0,0 +1,166 @@
+package tables
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/jsavajols/goframework/functions/database"
+	"github.com/jsavajols/goframework/functions/dialects"
+	"github.com/jsavajols/goframework/functions/fstrings"
+	sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+
+	logs "github.com/jsavajols/goframework/functions/logs"
+)
+
+// Upsert insère l'enregistrement ou le met à jour s'il existe déjà une ligne
+// avec les mêmes valeurs pour les colonnes keys (clé primaire ou index unique)
+// fields est la liste des colonnes au format de Insert : "(a, b, c)" et doit contenir keys
+// Selon l'existence de la ligne, BeforeInsert/AfterInsert ou BeforeUpdate/AfterUpdate sont appelés
+// et InsertRecords ou UpdateRecords vaut 1
+func (t *Table) Upsert(fields string, values []interface{}, keys []string) ReturnFunction {
+	return t.UpsertContext(context.Background(), fields, values, keys)
+}
+
+// UpsertContext version de Upsert interrompue à l'annulation de ctx
+func (t *Table) UpsertContext(ctx context.Context, fields string, values []interface{}, keys []string) ReturnFunction {
+	ctx, cancel := t.withTimeout(ctx)
+	defer cancel()
+	logs.Logs("Début de l'upsert dans", t.TableName)
+	if t.ReadOnly {
+		return failure(ctx, "Upsert error", ErrReadOnly)
+	}
+
+	columns := splitFields(fields)
+	if len(columns) != len(values) || len(keys) == 0 {
+		return failure(ctx, "Upsert error", validation(errors.New("Le nombre de champs ne correspond pas au nombre de valeurs ou aucune clé")))
+	}
+	keyFilter := make([]string, len(keys))
+	keyValues := make([]interface{}, len(keys))
+	for i, key := range keys {
+		index := -1
+		for j, column := range columns {
+			if column == key {
+				index = j
+			}
+		}
+		if index == -1 {
+			return failure(ctx, "Upsert error", validation(errors.New("La clé "+key+" n'est pas dans les champs")))
+		}
+		keyFilter[i] = key + " = ?"
+		keyValues[i] = values[index]
+	}
+
+	// Utilise la transaction de la table ou en démarre une
+	table := *t
+	ownTx := table.Tx == nil
+	if ownTx {
+		tx, err := t.BeginContext(ctx)
+		if err != nil {
+			return failure(ctx, "Upsert error", err)
+		}
+		table.Tx = tx
+	}
+	t.Dialect = table.Tx.Dialect
+	table.Dialect = t.Dialect
+
+	returnFunction, err := table.upsert(ctx, fields, columns, values, keys, keyFilter, keyValues)
+	if err == nil && ownTx {
+		err = table.Tx.Commit()
+	} else if err != nil {
+		if ownTx {
+			table.Tx.Rollback()
+		} else {
+			table.abort(err)
+		}
+	}
+	if err != nil {
+		log.Error(err.Error())
+		return failure(ctx, "Upsert error", err)
+	}
+	logs.Logs("Upsert réussi dans", t.TableName, returnFunction.Message)
+	database.MarkWrite(ctx)
+	return returnFunction
+}
+
+func (t Table) upsert(ctx context.Context, fields string, columns []string, values []interface{}, keys, keyFilter []string, keyValues []interface{}) (ReturnFunction, error) {
+	if err := t.bindTx(); err != nil {
+		return ReturnFunction{}, err
+	}
+
+	// Recherche de la ligne pour appeler les hooks correspondants
+	var found int
+	query := sqlFunctions.Rebind("SELECT 1 FROM "+t.TableName+" WHERE "+strings.Join(keyFilter, " and "), t.Dialect)
+	err := t.Tx.QueryRowContext(ctx, query, keyValues...).Scan(&found)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ReturnFunction{}, err
+	}
+	exists := err == nil
+	record := &Record{Fields: append([]string{}, columns...), Values: append([]interface{}{}, values...)}
+	if exists {
+		record.Filter, record.Args = strings.Join(keyFilter, " and "), keyValues
+		err = t.beforeUpdate(ctx, record)
+	} else {
+		err = t.beforeInsert(ctx, record)
+	}
+	if err == nil {
+		err = t.checkValues(ctx, record.Fields, record.Values, true)
+	}
+	if err != nil {
+		return ReturnFunction{}, validation(err)
+	}
+	// Les hooks ont pu modifier les colonnes et les valeurs
+	columns, values = record.Fields, record.Values
+	fields = "(" + strings.Join(columns, ", ") + ")"
+
+	// Colonnes mises à jour en cas de conflit : toutes sauf les clés
+	var updates []string
+	for _, column := range columns {
+		if !fstrings.ElementExistsInArray(column, keys) {
+			updates = append(updates, column)
+		}
+	}
+	query, mode := t.dialect().Upsert(t.TableName, fields, sqlFunctions.Placeholders(0, len(values), t.Dialect), keys, updates)
+	logs.Logs(query, values)
+
+	inserted := !exists
+	var lastInsertId int64
+	switch mode {
+	case dialects.UpsertReturning:
+		err = t.Tx.QueryRowContext(ctx, query, values...).Scan(&inserted)
+		if errors.Is(err, sql.ErrNoRows) {
+			inserted, err = false, nil
+		}
+	default:
+		var result sql.Result
+		result, err = t.Tx.ExecContext(ctx, query, values...)
+		if err == nil && mode == dialects.UpsertRowsAffected {
+			rowsAffected, _ := result.RowsAffected()
+			inserted = rowsAffected == 1
+		}
+		if err == nil && inserted {
+			lastInsertId, _ = result.LastInsertId()
+		}
+	}
+	if err != nil {
+		return ReturnFunction{}, err
+	}
+
+	if inserted {
+		result := ReturnFunction{
+			StatusCode:    200,
+			Message:       "Upsert inserted",
+			InsertRecords: 1,
+			LastInsertId:  lastInsertId,
+		}
+		return result, t.afterInsert(ctx, record, result)
+	}
+	result := ReturnFunction{
+		StatusCode:    200,
+		Message:       "Upsert updated",
+		UpdateRecords: 1,
+	}
+	return result, t.afterUpdate(ctx, record, result)
+}
@@ -0,0 +1,292 @@
+package tables
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/jsavajols/goframework/functions/mails"
+)
+
+// FieldError erreur de validation d'une colonne, Rule est la règle non respectée
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors erreurs de validation des colonnes d'un enregistrement,
+// retournées dans ReturnFunction.FieldErrors
+type ValidationErrors []FieldError
+
+func (v ValidationErrors) Error() string {
+	messages := make([]string, len(v))
+	for i, e := range v {
+		messages[i] = e.Message
+	}
+	return strings.Join(messages, " ; ")
+}
+
+// rule règle de validation d'une colonne
+type rule struct {
+	name   string
+	number float64
+	values []string
+	regex  *regexp.Regexp
+}
+
+// fieldRules règles de validation d'une colonne de Table.Struct
+type fieldRules struct {
+	column string
+	rules  []rule
+}
+
+var rulesCache sync.Map
+
+// structRules lit les règles du tag validate des champs de la structure :
+//
+//	Name  string `db:"name" validate:"required,min=2,max=50"`
+//	Email string `db:"email" validate:"email"`
+//	Role  string `db:"role" validate:"enum=admin|user"`
+//	Age   int    `db:"age" validate:"min=18,max=130"`
+//	Code  string `db:"code" validate:"regex=^[A-Z]{3}[0-9]+$"`
+//
+// min et max portent sur la longueur des chaines et sur la valeur des nombres.
+// regex reprend toute la fin du tag et doit donc être la dernière règle
+func structRules(typ reflect.Type) ([]fieldRules, error) {
+	if cached, ok := rulesCache.Load(typ); ok {
+		return cached.([]fieldRules), nil
+	}
+	var result []fieldRules
+	for _, field := range structFields(typ) {
+		tag := typ.FieldByIndex(field.Index).Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+		rules, err := parseRules(tag)
+		if err != nil {
+			return nil, fmt.Errorf("Tag validate de la colonne %s : %w", field.Column, err)
+		}
+		result = append(result, fieldRules{column: field.Column, rules: rules})
+	}
+	rulesCache.Store(typ, result)
+	return result, nil
+}
+
+// parseRules lit les règles d'un tag validate
+func parseRules(tag string) ([]rule, error) {
+	var rules []rule
+	for tag != "" {
+		var option string
+		if strings.HasPrefix(tag, "regex=") {
+			option, tag = tag, ""
+		} else if i := strings.Index(tag, ","); i >= 0 {
+			option, tag = tag[:i], tag[i+1:]
+		} else {
+			option, tag = tag, ""
+		}
+		name, param, _ := strings.Cut(strings.TrimSpace(option), "=")
+		r := rule{name: name}
+		switch name {
+		case "required", "email":
+		case "min", "max":
+			number, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				return nil, fmt.Errorf("valeur invalide pour %s : %s", name, param)
+			}
+			r.number = number
+		case "enum":
+			if param == "" {
+				return nil, fmt.Errorf("aucune valeur pour enum")
+			}
+			r.values = strings.Split(param, "|")
+		case "regex":
+			regex, err := regexp.Compile(param)
+			if err != nil {
+				return nil, err
+			}
+			r.regex = regex
+		default:
+			return nil, fmt.Errorf("règle inconnue : %s", name)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// ValidateValues vérifie les valeurs avec les règles du tag validate de Struct.
+// Pour une insertion, les colonnes required absentes de fields sont aussi en erreur.
+// Retourne une ValidationErrors contenant une erreur par règle non respectée
+func (t Table) ValidateValues(fields []string, values []interface{}, insert bool) error {
+	if t.Struct == nil {
+		return nil
+	}
+	typ, err := structType(t.Struct)
+	if err != nil {
+		return err
+	}
+	rules, err := structRules(typ)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	var errs ValidationErrors
+	for _, field := range rules {
+		index := -1
+		for i, name := range fields {
+			if strings.EqualFold(name, field.column) {
+				index = i
+				break
+			}
+		}
+		if index < 0 || index >= len(values) {
+			if insert && hasRule(field.rules, "required") {
+				errs = append(errs, FieldError{Field: field.column, Rule: "required",
+					Message: fmt.Sprintf("La colonne %s est obligatoire", field.column)})
+			}
+			continue
+		}
+		errs = append(errs, checkRules(field.column, field.rules, values[index])...)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkValues vérifie les valeurs avec les règles de Struct puis avec le schéma si CheckSchema est vrai
+func (t Table) checkValues(ctx context.Context, fields []string, values []interface{}, insert bool) error {
+	if err := t.ValidateValues(fields, values, insert); err != nil {
+		return err
+	}
+	return t.checkSchema(ctx, fields, values, insert)
+}
+
+// fieldErrors retourne les erreurs par colonne contenues dans err
+func fieldErrors(err error) []FieldError {
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		return errs
+	}
+	return nil
+}
+
+func hasRule(rules []rule, name string) bool {
+	for _, r := range rules {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// checkRules retourne les règles non respectées par la valeur de la colonne
+// Une valeur nulle ou vide n'est contrôlée que par required
+func checkRules(column string, rules []rule, value interface{}) []FieldError {
+	value = indirect(value)
+	if value == nil || value == "" {
+		if hasRule(rules, "required") {
+			return []FieldError{{Field: column, Rule: "required", Message: fmt.Sprintf("La colonne %s est obligatoire", column)}}
+		}
+		return nil
+	}
+	var errs []FieldError
+	for _, r := range rules {
+		if message := r.check(column, value); message != "" {
+			errs = append(errs, FieldError{Field: column, Rule: r.name, Message: message})
+		}
+	}
+	return errs
+}
+
+// check retourne le message d'erreur si la valeur ne respecte pas la règle
+func (r rule) check(column string, value interface{}) string {
+	switch r.name {
+	case "min", "max":
+		if s, ok := value.(string); ok {
+			length := float64(utf8.RuneCountInString(s))
+			if r.name == "min" && length < r.number {
+				return fmt.Sprintf("La colonne %s doit contenir au moins %v caractères", column, r.number)
+			}
+			if r.name == "max" && length > r.number {
+				return fmt.Sprintf("La colonne %s doit contenir au plus %v caractères", column, r.number)
+			}
+			return ""
+		}
+		number, ok := toFloat(value)
+		if !ok {
+			return fmt.Sprintf("La colonne %s doit être une chaine ou un nombre", column)
+		}
+		if r.name == "min" && number < r.number {
+			return fmt.Sprintf("La colonne %s doit être supérieure ou égale à %v", column, r.number)
+		}
+		if r.name == "max" && number > r.number {
+			return fmt.Sprintf("La colonne %s doit être inférieure ou égale à %v", column, r.number)
+		}
+	case "enum":
+		text := fmt.Sprint(value)
+		for _, v := range r.values {
+			if v == text {
+				return ""
+			}
+		}
+		return fmt.Sprintf("La colonne %s doit valoir %s", column, strings.Join(r.values, ", "))
+	case "regex":
+		if !r.regex.MatchString(fmt.Sprint(value)) {
+			return fmt.Sprintf("La colonne %s ne respecte pas le format attendu", column)
+		}
+	case "email":
+		s, ok := value.(string)
+		if !ok || mails.ChkMailSyntax(strings.ToLower(s)) != 0 {
+			return fmt.Sprintf("La colonne %s n'est pas une adresse mail valide", column)
+		}
+	}
+	return ""
+}
+
+// indirect retourne la valeur pointée (nil pour un pointeur nil), les []byte sont convertis
+// en chaine et les json.Number en nombre
+func indirect(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	switch value := v.Interface().(type) {
+	case []byte:
+		return string(value)
+	case json.Number:
+		if number, err := value.Float64(); err == nil {
+			return number
+		}
+	}
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return v.Interface()
+}
+
+// toFloat convertit un nombre en float64
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
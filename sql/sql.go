@@ -1,13 +1,13 @@
 package sql
 
+import sqlFunctions "github.com/jsavajols/goframework/functions/sql"
+
+// DateDiff expression de différence entre deux dates littérales (2024-01-31) pour le dialecte,
+// les dates sont entre quotes. Retourne une chaine vide si le dialecte est vide ou inconnu
+// Pour postgres, la différence est celle des dates (DATE_PART sur l'intervalle)
+// et non plus la différence des DATE_PART de chaque date
+//
+// Deprecated: utiliser functions/sql.DateDiff, qui accepte aussi des colonnes et des expressions
 func DateDiff(unit, date1, date2, dialect string) string {
-	if dialect == "mysql" {
-		return "DATEDIFF('" + date1 + "','" + date2 + "')"
-	} else if dialect == "postgres" {
-		return "DATE_PART('" + unit + "','" + date1 + "') - DATE_PART('" + unit + "','" + date2 + "')"
-	} else if dialect == "sqlite3" {
-		return "JULIANDAY('" + date1 + "') - JULIANDAY('" + date2 + "')"
-	} else {
-		return ""
-	}
+	return sqlFunctions.DateDiff(unit, "'"+date1+"'", "'"+date2+"'", dialect)
 }